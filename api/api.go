@@ -226,6 +226,8 @@ func (a *API) httpRequest(tpl string, p *params, method string) ([]byte, error)
 
 type params struct {
 	EventURN           uof.URN
+	URN                uof.URN
+	Date               string
 	ScenarioID         int
 	Speed              int
 	MaxDelay           int
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"os"
 	"testing"
@@ -15,6 +16,42 @@ import (
 func TestTemplate(t *testing.T) {
 	path := runTemplate(startScenario, &params{ScenarioID: 1, Speed: 2, MaxDelay: 3})
 	assert.Equal(t, "/v1/replay/scenario/play/1?speed=2&max_delay=3&use_replay_timestamp=false", path)
+
+	path = runTemplate(pathCategories, &params{Lang: uof.LangEN, URN: "sr:sport:1"})
+	assert.Equal(t, "/v1/sports/en/sports/sr:sport:1/categories.xml", path)
+	path = runTemplate(pathDateSchedule, &params{Lang: uof.LangEN, Date: "2020-10-19"})
+	assert.Equal(t, "/v1/sports/en/schedules/2020-10-19/schedule.xml", path)
+	after := time.Date(2020, 10, 19, 12, 30, 0, 0, time.FixedZone("CEST", 7200))
+	path = runTemplate(pathFixtureChgs, &params{Lang: uof.LangEN, Date: changesTime(after)})
+	assert.Equal(t, "/v1/sports/en/fixtures/changes.xml?afterDateTime=2020-10-19T10:30:00Z", path)
+}
+
+func TestEventChanges(t *testing.T) {
+	buf := `<fixture_changes generated_at="2020-10-19T10:31:00+00:00">
+		<fixture_change sport_event_id="sr:match:1234" update_time="2020-10-19T10:30:05+00:00"/>
+		<fixture_change sport_event_id="sr:stage:55" update_time="2020-10-19T10:30:07+00:00"/>
+	</fixture_changes>`
+	var cr fixtureChangesRsp
+	assert.NoError(t, xml.Unmarshal([]byte(buf), &cr))
+	cs := eventChanges(cr.Changes)
+	assert.Len(t, cs, 2)
+	assert.Equal(t, 1234, cs[0].EventID)
+	assert.Equal(t, uof.URN("sr:stage:55"), cs[1].EventURN)
+	assert.Equal(t, 7, cs[1].UpdateTime.Second())
+}
+
+func TestScheduleGeneratedAt(t *testing.T) {
+	buf := `<schedule generated_at="2020-10-19T10:31:00+00:00">
+		<sport_event id="sr:match:1" scheduled="2020-10-19T18:00:00+00:00"/>
+		<sport_event id="sr:match:2" scheduled="2020-10-19T19:00:00+00:00"/>
+	</schedule>`
+	var sr scheduleRsp
+	assert.NoError(t, xml.Unmarshal([]byte(buf), &sr))
+	xs := sr.fixtures()
+	assert.Len(t, xs, 2)
+	for _, x := range xs {
+		assert.Equal(t, 31, x.GeneratedAt.Minute())
+	}
 }
 
 const EnvToken = "UOF_TOKEN"
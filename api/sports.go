@@ -16,6 +16,15 @@ const (
 	pathTimeline      = "/v1/sports/{{.Lang}}/sport_events/{{.EventURN}}/timeline.xml"
 	pathPlayer        = "/v1/sports/{{.Lang}}/players/sr:player:{{.PlayerID}}/profile.xml"
 	pathCompetitor    = "/v1/sports/{{.Lang}}/competitors/sr:competitor:{{.PlayerID}}/profile.xml"
+	pathSports        = "/v1/sports/{{.Lang}}/sports.xml"
+	pathCategories    = "/v1/sports/{{.Lang}}/sports/{{.URN}}/categories.xml"
+	pathTournaments   = "/v1/sports/{{.Lang}}/tournaments.xml"
+	pathTournament    = "/v1/sports/{{.Lang}}/tournaments/{{.URN}}/info.xml"
+	pathSeasons       = "/v1/sports/{{.Lang}}/tournaments/{{.URN}}/seasons.xml"
+	pathTournamentSch = "/v1/sports/{{.Lang}}/tournaments/{{.URN}}/schedule.xml"
+	pathDateSchedule  = "/v1/sports/{{.Lang}}/schedules/{{.Date}}/schedule.xml"
+	pathFixtureChgs   = "/v1/sports/{{.Lang}}/fixtures/changes.xml?afterDateTime={{.Date}}"
+	pathResultChgs    = "/v1/sports/{{.Lang}}/results/changes.xml?afterDateTime={{.Date}}"
 	events            = "/v1/sports/{{.Lang}}/schedules/pre/schedule.xml?start={{.Start}}&limit={{.Limit}}"
 	liveEvents        = "/v1/sports/{{.Lang}}/schedules/live/schedule.xml"
 	replayFixture     = "/v1/replay/sports/{{.Lang}}/sport_events/{{.EventURN}}/fixture.xml"
@@ -42,12 +51,13 @@ func (a *API) MarketVariant(lang uof.Lang, marketID int, variant string) (uof.Ma
 // Fixture lists the fixture for a specified sport event
 func (a *API) Fixture(lang uof.Lang, eventURN uof.URN) (*uof.Fixture, error) {
 	var fr fixtureRsp
+	tpl := pathFixture
 	if a.env == uof.Replay {
-		return &fr.Fixture, a.getAs(&fr, replayFixture, &params{Lang: lang, EventURN: eventURN})
-
+		tpl = replayFixture
 	}
-	return &fr.Fixture, a.getAs(&fr, pathFixture, &params{Lang: lang, EventURN: eventURN})
-
+	err := a.getAs(&fr, tpl, &params{Lang: lang, EventURN: eventURN})
+	fr.Fixture.GeneratedAt = fr.GeneratedAt
+	return &fr.Fixture, err
 }
 
 // Summary with extra information
@@ -78,9 +88,90 @@ func (a *API) Player(lang uof.Lang, playerID int) (*uof.Player, error) {
 	return &pr.Player, a.getAs(&pr, pathPlayer, &params{Lang: lang, PlayerID: playerID})
 }
 
-func (a *API) Competitor(lang uof.Lang, playerID int) (*uof.CompetitorPlayer, error) {
-	var cr competitorRsp
-	return &cr.Competitor, a.getAs(&cr, pathCompetitor, &params{Lang: lang, PlayerID: playerID})
+// Competitor profile with players, jerseys, manager and venue.
+func (a *API) Competitor(lang uof.Lang, competitorID int) (*uof.CompetitorProfile, error) {
+	var cp uof.CompetitorProfile
+	return &cp, a.getAs(&cp, pathCompetitor, &params{Lang: lang, PlayerID: competitorID})
+}
+
+// Sports lists all available sports.
+func (a *API) Sports(lang uof.Lang) ([]uof.Sport, error) {
+	var sr sportsRsp
+	return sr.Sports, a.getAs(&sr, pathSports, &params{Lang: lang})
+}
+
+// Categories of the sport.
+func (a *API) Categories(lang uof.Lang, sportURN uof.URN) (*uof.SportCategories, error) {
+	var sc uof.SportCategories
+	return &sc, a.getAs(&sc, pathCategories, &params{Lang: lang, URN: sportURN})
+}
+
+// Tournaments lists all available tournaments with their sport, category and
+// current season.
+func (a *API) Tournaments(lang uof.Lang) ([]uof.CatalogueTournament, error) {
+	var tr tournamentsRsp
+	return tr.Tournaments, a.getAs(&tr, pathTournaments, &params{Lang: lang})
+}
+
+// TournamentInfo details of the tournament.
+func (a *API) TournamentInfo(lang uof.Lang, tournamentURN uof.URN) (*uof.TournamentInfo, error) {
+	var ti uof.TournamentInfo
+	return &ti, a.getAs(&ti, pathTournament, &params{Lang: lang, URN: tournamentURN})
+}
+
+// Seasons of the tournament.
+func (a *API) Seasons(lang uof.Lang, tournamentURN uof.URN) ([]uof.Season, error) {
+	var sr seasonsRsp
+	return sr.Seasons, a.getAs(&sr, pathSeasons, &params{Lang: lang, URN: tournamentURN})
+}
+
+// TournamentSchedule lists sport events of the tournament.
+func (a *API) TournamentSchedule(lang uof.Lang, tournamentURN uof.URN) ([]uof.Fixture, error) {
+	var sr tournamentScheduleRsp
+	return sr.Fixtures, a.getAs(&sr, pathTournamentSch, &params{Lang: lang, URN: tournamentURN})
+}
+
+// Schedule lists sport events scheduled for the date.
+func (a *API) Schedule(lang uof.Lang, date time.Time) ([]uof.Fixture, error) {
+	var sr scheduleRsp
+	if err := a.getAs(&sr, pathDateSchedule, &params{Lang: lang, Date: date.Format("2006-01-02")}); err != nil {
+		return nil, err
+	}
+	return sr.fixtures(), nil
+}
+
+// LiveSchedule lists sport events which are currently live.
+func (a *API) LiveSchedule(lang uof.Lang) ([]uof.Fixture, error) {
+	var sr scheduleRsp
+	if err := a.getAs(&sr, liveEvents, &params{Lang: lang}); err != nil {
+		return nil, err
+	}
+	return sr.fixtures(), nil
+}
+
+// FixtureChanges lists events with fixture changed after the time.
+func (a *API) FixtureChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	var cr fixtureChangesRsp
+	err := a.getAs(&cr, pathFixtureChgs, &params{Lang: lang, Date: changesTime(after)})
+	return eventChanges(cr.Changes), err
+}
+
+// ResultChanges lists events with results changed after the time.
+func (a *API) ResultChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	var cr resultChangesRsp
+	err := a.getAs(&cr, pathResultChgs, &params{Lang: lang, Date: changesTime(after)})
+	return eventChanges(cr.Changes), err
+}
+
+func changesTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+func eventChanges(cs []uof.EventChange) []uof.EventChange {
+	for i, c := range cs {
+		cs[i].EventID = c.EventURN.EventID()
+	}
+	return cs
 }
 
 type marketsRsp struct {
@@ -102,21 +193,48 @@ type playerRsp struct {
 	GeneratedAt time.Time  `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
 }
 
-type competitorRsp struct {
-	Competitor  uof.CompetitorPlayer `xml:"competitor" json:"competitor"`
-	GeneratedAt time.Time            `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
-}
-
 type fixtureRsp struct {
 	Fixture     uof.Fixture `xml:"fixture" json:"fixture"`
 	GeneratedAt time.Time   `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
 }
 
+type sportsRsp struct {
+	Sports []uof.Sport `xml:"sport,omitempty" json:"sports,omitempty"`
+}
+
+type tournamentsRsp struct {
+	Tournaments []uof.CatalogueTournament `xml:"tournament,omitempty" json:"tournaments,omitempty"`
+}
+
+type seasonsRsp struct {
+	Seasons []uof.Season `xml:"seasons>season,omitempty" json:"seasons,omitempty"`
+}
+
+type tournamentScheduleRsp struct {
+	Fixtures []uof.Fixture `xml:"sport_events>sport_event,omitempty" json:"sportEvents,omitempty"`
+}
+
+type fixtureChangesRsp struct {
+	Changes []uof.EventChange `xml:"fixture_change,omitempty" json:"changes,omitempty"`
+}
+
+type resultChangesRsp struct {
+	Changes []uof.EventChange `xml:"result_change,omitempty" json:"changes,omitempty"`
+}
+
 type scheduleRsp struct {
 	Fixtures    []uof.Fixture `xml:"sport_event,omitempty" json:"sportEvent,omitempty"`
 	GeneratedAt time.Time     `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
 }
 
+// fixtures with generated at of the response
+func (sr scheduleRsp) fixtures() []uof.Fixture {
+	for i := range sr.Fixtures {
+		sr.Fixtures[i].GeneratedAt = sr.GeneratedAt
+	}
+	return sr.Fixtures
+}
+
 // Fixtures gets all the fixtures with schedule before to
 func (a *API) Fixtures(lang uof.Lang, to time.Time) (<-chan uof.Fixture, <-chan error) {
 	errc := make(chan error, 1)
@@ -132,6 +250,7 @@ func (a *API) Fixtures(lang uof.Lang, to time.Time) (<-chan uof.Fixture, <-chan
 				return uof.Notice("unmarshal", err)
 			}
 			for _, f := range sr.Fixtures {
+				f.GeneratedAt = sr.GeneratedAt
 				out <- f
 				if f.Scheduled.After(to) {
 					done = true
@@ -34,6 +34,7 @@ type BetSettlementOutcome struct {
 	VariantURN     URN           `json:"variantURN"`
 	Result         OutcomeResult `json:"result"`
 	DeadHeatFactor float64       `json:"deadHeatFactor,omitempty"`
+	VoidFactor     float64       `json:"voidFactor,omitempty"`
 }
 
 type RollbackBetSettlement struct {
@@ -104,9 +105,12 @@ func (t *BetSettlementOutcome) UnmarshalXML(d *xml.Decoder, start xml.StartEleme
 	t.PlayerID = toPlayerID(overlay.ID)
 	t.VariantURN = toVariantURN(overlay.ID)
 	t.Result = toResult(overlay.Result, overlay.VoidFactor, overlay.DeadHeatFactor)
-	if t.Result == OutcomeResultWinWithDeadHead && overlay.DeadHeatFactor != nil {
+	if overlay.DeadHeatFactor != nil {
 		t.DeadHeatFactor = *overlay.DeadHeatFactor
 	}
+	if overlay.VoidFactor != nil {
+		t.VoidFactor = *overlay.VoidFactor
+	}
 	return nil
 }
 
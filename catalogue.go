@@ -0,0 +1,124 @@
+package uof
+
+import (
+	"encoding/xml"
+	"sort"
+	"time"
+)
+
+// CatalogueTournament is tournament with its sport, category and current
+// season, as listed in tournaments catalogue.
+type CatalogueTournament struct {
+	ID            int      `json:"id"`
+	URN           URN      `json:"urn"`
+	Name          string   `xml:"name,attr" json:"name"`
+	Sport         Sport    `xml:"sport" json:"sport"`
+	Category      Category `xml:"category" json:"category"`
+	CurrentSeason *Season  `xml:"current_season,omitempty" json:"currentSeason,omitempty"`
+}
+
+// SportCategories all categories of the sport.
+type SportCategories struct {
+	Sport      Sport      `xml:"sport" json:"sport"`
+	Categories []Category `xml:"categories>category,omitempty" json:"categories,omitempty"`
+}
+
+// TournamentInfo details of the tournament with the current season and
+// groups of competitors.
+type TournamentInfo struct {
+	Tournament  CatalogueTournament `xml:"tournament" json:"tournament"`
+	Season      *Season             `xml:"season,omitempty" json:"season,omitempty"`
+	Groups      []Group             `xml:"groups>group,omitempty" json:"groups,omitempty"`
+	GeneratedAt time.Time           `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
+}
+
+// Catalogue is navigation tree of sports, categories and tournaments in one
+// language.
+type Catalogue struct {
+	Sports []CatalogueSport `json:"sports"`
+}
+
+type CatalogueSport struct {
+	Sport
+	Categories []CatalogueCategory `json:"categories,omitempty"`
+}
+
+type CatalogueCategory struct {
+	Category
+	Tournaments []CatalogueTournament `json:"tournaments,omitempty"`
+}
+
+// NewCatalogue builds catalogue tree from the list of sports and tournaments.
+// Categories are taken from tournaments, sports without tournaments are
+// included without categories. Sports are in the order of the list,
+// categories and tournaments sorted by name.
+func NewCatalogue(sports []Sport, tournaments []CatalogueTournament) *Catalogue {
+	c := &Catalogue{}
+	sportIdx := make(map[URN]int)
+	for _, s := range sports {
+		sportIdx[s.URN] = len(c.Sports)
+		c.Sports = append(c.Sports, CatalogueSport{Sport: s})
+	}
+	for _, t := range tournaments {
+		si, ok := sportIdx[t.Sport.URN]
+		if !ok {
+			si = len(c.Sports)
+			sportIdx[t.Sport.URN] = si
+			c.Sports = append(c.Sports, CatalogueSport{Sport: t.Sport})
+		}
+		s := &c.Sports[si]
+		ci := -1
+		for i, cat := range s.Categories {
+			if cat.URN == t.Category.URN {
+				ci = i
+				break
+			}
+		}
+		if ci < 0 {
+			ci = len(s.Categories)
+			s.Categories = append(s.Categories, CatalogueCategory{Category: t.Category})
+		}
+		s.Categories[ci].Tournaments = append(s.Categories[ci].Tournaments, t)
+	}
+	for _, s := range c.Sports {
+		sort.SliceStable(s.Categories, func(i, j int) bool {
+			return s.Categories[i].Name < s.Categories[j].Name
+		})
+		for _, cat := range s.Categories {
+			ts := cat.Tournaments
+			sort.SliceStable(ts, func(i, j int) bool {
+				return ts[i].Name < ts[j].Name
+			})
+		}
+	}
+	return c
+}
+
+// Find tournament in the catalogue.
+func (c *Catalogue) Find(tournamentURN URN) (CatalogueTournament, bool) {
+	for _, s := range c.Sports {
+		for _, cat := range s.Categories {
+			for _, t := range cat.Tournaments {
+				if t.URN == tournamentURN {
+					return t, true
+				}
+			}
+		}
+	}
+	return CatalogueTournament{}, false
+}
+
+func (t *CatalogueTournament) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T CatalogueTournament
+	var overlay struct {
+		*T
+		URN URN `xml:"id,attr"`
+	}
+	overlay.T = (*T)(t)
+	if err := d.DecodeElement(&overlay, &start); err != nil {
+		return err
+	}
+	t.ID = overlay.URN.ID()
+	t.URN = overlay.URN
+	return nil
+}
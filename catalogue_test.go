@@ -0,0 +1,64 @@
+package uof
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tournamentsXML = `<tournaments>
+	<tournament id="sr:tournament:17" name="Premier League">
+		<sport id="sr:sport:1" name="Soccer"/>
+		<category id="sr:category:1" name="England"/>
+		<current_season id="sr:season:77179" name="Premier League 20/21" start_date="2020-09-12" end_date="2021-05-24" year="20/21"/>
+	</tournament>
+	<tournament id="sr:tournament:18" name="Championship">
+		<sport id="sr:sport:1" name="Soccer"/>
+		<category id="sr:category:1" name="England"/>
+	</tournament>
+	<tournament id="sr:tournament:34" name="Ligue 1">
+		<sport id="sr:sport:1" name="Soccer"/>
+		<category id="sr:category:7" name="France"/>
+	</tournament>
+	<tournament id="sr:tournament:2555" name="ATP Vienna">
+		<sport id="sr:sport:5" name="Tennis"/>
+		<category id="sr:category:3" name="ATP"/>
+	</tournament>
+</tournaments>`
+
+func TestCatalogue(t *testing.T) {
+	var rsp struct {
+		Tournaments []CatalogueTournament `xml:"tournament"`
+	}
+	err := xml.Unmarshal([]byte(tournamentsXML), &rsp)
+	assert.NoError(t, err)
+	ts := rsp.Tournaments
+	assert.Len(t, ts, 4)
+	assert.Equal(t, 17, ts[0].ID)
+	assert.Equal(t, 1, ts[0].Sport.ID)
+	assert.Equal(t, "England", ts[0].Category.Name)
+	assert.Equal(t, 77179, ts[0].CurrentSeason.ID)
+	assert.Nil(t, ts[1].CurrentSeason)
+
+	sports := []Sport{
+		{ID: 1, URN: "sr:sport:1", Name: "Soccer"},
+		{ID: 2, URN: "sr:sport:2", Name: "Basketball"},
+	}
+	c := NewCatalogue(sports, ts)
+	assert.Len(t, c.Sports, 3)
+	assert.Equal(t, "Soccer", c.Sports[0].Name)
+	assert.Len(t, c.Sports[0].Categories, 2)
+	assert.Equal(t, "England", c.Sports[0].Categories[0].Name)
+	assert.Equal(t, "Championship", c.Sports[0].Categories[0].Tournaments[0].Name)
+	assert.Equal(t, "Premier League", c.Sports[0].Categories[0].Tournaments[1].Name)
+	assert.Len(t, c.Sports[1].Categories, 0)
+	// sport not in the list is added from the tournament
+	assert.Equal(t, "Tennis", c.Sports[2].Name)
+
+	tr, ok := c.Find("sr:tournament:34")
+	assert.True(t, ok)
+	assert.Equal(t, "France", tr.Category.Name)
+	_, ok = c.Find("sr:tournament:1")
+	assert.False(t, ok)
+}
@@ -1,10 +1,70 @@
 package uof
 
 import (
+	"encoding/xml"
 	"time"
 )
 
+// CompetitorProfile full competitor (team) profile with squad and team data.
 type CompetitorProfile struct {
-	Competitor  CompetitorPlayer `xml:"competitor" json:"competitor"`
-	GeneratedAt time.Time        `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
+	Competitor  Competitor `xml:"competitor" json:"competitor"`
+	GeneratedAt time.Time  `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
+	Venue       *Venue     `xml:"venue,omitempty" json:"venue,omitempty"`
+	Jerseys     []Jersey   `xml:"jerseys>jersey,omitempty" json:"jerseys,omitempty"`
+	Manager     *Manager   `xml:"manager,omitempty" json:"manager,omitempty"`
+	Players     []Player   `xml:"players>player,omitempty" json:"players,omitempty"`
+}
+
+// Jersey colours are hex rgb values (ffffff).
+type Jersey struct {
+	Type              string `xml:"type,attr" json:"type"` // home, away, goalkeeper, third
+	Base              string `xml:"base,attr,omitempty" json:"base,omitempty"`
+	Sleeve            string `xml:"sleeve,attr,omitempty" json:"sleeve,omitempty"`
+	Number            string `xml:"number,attr,omitempty" json:"number,omitempty"`
+	Stripes           bool   `xml:"stripes,attr,omitempty" json:"stripes,omitempty"`
+	StripesColor      string `xml:"stripes_color,attr,omitempty" json:"stripesColor,omitempty"`
+	HorizontalStripes bool   `xml:"horizontal_stripes,attr,omitempty" json:"horizontalStripes,omitempty"`
+	Squares           bool   `xml:"squares,attr,omitempty" json:"squares,omitempty"`
+	Split             bool   `xml:"split,attr,omitempty" json:"split,omitempty"`
+	ShirtType         string `xml:"shirt_type,attr,omitempty" json:"shirtType,omitempty"`
+	SleeveDetail      string `xml:"sleeve_detail,attr,omitempty" json:"sleeveDetail,omitempty"`
+}
+
+type Manager struct {
+	ID          int    `json:"id"`
+	URN         URN    `json:"urn"`
+	Name        string `xml:"name,attr" json:"name"`
+	Nationality string `xml:"nationality,attr,omitempty" json:"nationality,omitempty"`
+	CountryCode string `xml:"country_code,attr,omitempty" json:"countryCode,omitempty"`
+}
+
+// ReferenceID of the competitor in other systems (betradar, rotation number...).
+type ReferenceID struct {
+	Name  string `xml:"name,attr" json:"name"`
+	Value string `xml:"value,attr" json:"value"`
+}
+
+// Reference returns competitor reference id with the name.
+func (c Competitor) Reference(name string) string {
+	for _, r := range c.ReferenceIDs {
+		if r.Name == name {
+			return r.Value
+		}
+	}
+	return ""
+}
+
+func (t *Manager) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T Manager
+	var overlay struct {
+		*T
+		URN URN `xml:"id,attr"`
+	}
+	overlay.T = (*T)(t)
+	if err := d.DecodeElement(&overlay, &start); err != nil {
+		return err
+	}
+	t.ID = overlay.URN.ID()
+	t.URN = overlay.URN
+	return nil
 }
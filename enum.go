@@ -257,6 +257,10 @@ func (u URN) split() (int, string) {
 	return id, prefix
 }
 
+func (u URN) IsCompetitor() bool {
+	return strings.HasPrefix(string(u), srCompetitor)
+}
+
 func (u URN) IsTournament() bool {
 	p := strings.Split(string(u), ":")
 	if len(p) != 3 {
@@ -336,6 +340,45 @@ const (
 	FixtureChangeTypeCoverage FixtureChangeType = 5
 )
 
+// TimelineEventKind is typed timeline event type.
+type TimelineEventKind int8
+
+const (
+	TimelineEventOther TimelineEventKind = iota
+	TimelineEventGoal
+	TimelineEventYellowCard
+	TimelineEventRedCard
+	TimelineEventYellowRedCard
+	TimelineEventSubstitution
+	TimelineEventPeriodStart
+	TimelineEventPeriodScore
+	TimelineEventBreakStart
+	TimelineEventMatchStarted
+	TimelineEventMatchEnded
+)
+
+var timelineEventKinds = map[string]TimelineEventKind{
+	"score_change":    TimelineEventGoal,
+	"yellow_card":     TimelineEventYellowCard,
+	"red_card":        TimelineEventRedCard,
+	"yellow_red_card": TimelineEventYellowRedCard,
+	"substitution":    TimelineEventSubstitution,
+	"period_start":    TimelineEventPeriodStart,
+	"period_score":    TimelineEventPeriodScore,
+	"break_start":     TimelineEventBreakStart,
+	"match_started":   TimelineEventMatchStarted,
+	"match_ended":     TimelineEventMatchEnded,
+}
+
+func toTimelineEventKind(typ string) TimelineEventKind {
+	return timelineEventKinds[typ]
+}
+
+// IsCard reports whether event is any of the cards.
+func (k TimelineEventKind) IsCard() bool {
+	return k == TimelineEventYellowCard || k == TimelineEventRedCard || k == TimelineEventYellowRedCard
+}
+
 type MessageType int8
 
 const (
@@ -351,6 +394,12 @@ const (
 	MessageTypeBetStop
 	MessageTypeRollbackBetSettlement
 	MessageTypeRollbackBetCancel
+	// sdk event message types
+	MessageTypeSettlementChange
+	MessageTypeSettlementDiscrepancy
+	MessageTypeEventTransition
+	MessageTypeFixtureDiff
+	MessageTypeOddsDiff
 )
 
 // api message types
@@ -361,6 +410,8 @@ const (
 	MessageTypeCompetitor
 	MessageTypeTournament
 	MessageTypeSummary
+	MessageTypeTimeline
+	MessageTypeCatalogue
 )
 
 // system message types
@@ -369,6 +420,8 @@ const (
 	MessageTypeSnapshotComplete
 	MessageTypeConnection
 	MessageTypeProducersChange
+	MessageTypeConnectionStats
+	MessageTypeSlowConsumer
 )
 
 var messageTypes = []MessageType{
@@ -381,17 +434,27 @@ var messageTypes = []MessageType{
 	MessageTypeBetStop,
 	MessageTypeRollbackBetSettlement,
 	MessageTypeRollbackBetCancel,
+	MessageTypeSettlementChange,
+	MessageTypeSettlementDiscrepancy,
+	MessageTypeEventTransition,
+	MessageTypeFixtureDiff,
+	MessageTypeOddsDiff,
 
 	MessageTypeFixture,
 	MessageTypeMarkets,
 	MessageTypePlayer,
 	MessageTypeCompetitor,
 	MessageTypeTournament,
+	MessageTypeSummary,
+	MessageTypeTimeline,
+	MessageTypeCatalogue,
 
 	MessageTypeAlive,
 	MessageTypeSnapshotComplete,
 	MessageTypeConnection,
 	MessageTypeProducersChange,
+	MessageTypeConnectionStats,
+	MessageTypeSlowConsumer,
 }
 
 var messageTypeNames = []string{
@@ -404,17 +467,27 @@ var messageTypeNames = []string{
 	"bet_stop",
 	"rollback_bet_settlement",
 	"rollback_bet_cancel",
+	"settlement_change",
+	"settlement_discrepancy",
+	"event_transition",
+	"fixture_diff",
+	"odds_diff",
 
 	"fixture",
 	"market",
 	"player",
 	"competitor",
 	"tournament",
+	"summary",
+	"timeline",
+	"catalogue",
 
 	"alive",
 	"snapshot_complete",
 	"connection",
 	"producer_change",
+	"connection_stats",
+	"slow_consumer",
 }
 
 func (m *MessageType) Parse(name string) {
@@ -466,6 +539,22 @@ const (
 	MessageScopeSystem // system scope messages, like alive, product down
 )
 
+// producerScope is the scope of the messages made by the sdk for the events
+// of the producer. System scope when producer is not known.
+func producerScope(p Producer) MessageScope {
+	switch {
+	case p.Virtuals():
+		return MessageScopeVirtuals
+	case p.Scope() == "live|prematch":
+		return MessageScopePrematchAndLive
+	case p.Scope() == "prematch":
+		return MessageScopePrematch
+	case p.Scope() == "live":
+		return MessageScopeLive
+	}
+	return MessageScopeSystem
+}
+
 func (s *MessageScope) Parse(prematchInterest, liveInterest string) {
 	v := func() MessageScope {
 		if prematchInterest == "pre" {
@@ -1,6 +1,9 @@
 package uof
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Inspiration:
 //   https://peter.bourgon.org/blog/2019/09/11/programming-with-errors.html
@@ -53,6 +56,36 @@ func (e APIError) Unwrap() error {
 	return e.Inner
 }
 
+// ReconnectError is reported for each failed attempt to reconnect to the queue.
+type ReconnectError struct {
+	Attempt int
+	Inner   error
+}
+
+func (e ReconnectError) Error() string {
+	return fmt.Sprintf("uof reconnect attempt %d failed, inner: %v", e.Attempt, e.Inner)
+}
+
+func (e ReconnectError) Unwrap() error {
+	return e.Inner
+}
+
+// ReconnectGiveUpError is terminal error, reconnect policy is exhausted and the
+// queue connection is closed.
+type ReconnectGiveUpError struct {
+	Attempts int
+	Elapsed  time.Duration
+	Inner    error // last attempt error
+}
+
+func (e ReconnectGiveUpError) Error() string {
+	return fmt.Sprintf("uof reconnect gave up after %d attempts in %s, inner: %v", e.Attempts, e.Elapsed, e.Inner)
+}
+
+func (e ReconnectGiveUpError) Unwrap() error {
+	return e.Inner
+}
+
 func E(op string, inner error) Error {
 	return Error{
 		Severity: LogSeverity,
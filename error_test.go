@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -41,3 +42,20 @@ func TestInnerError(t *testing.T) {
 
 	assert.Equal(t, "NOTICE uof error op: operation, inner: some inner error", ue.Error())
 }
+
+func TestReconnectError(t *testing.T) {
+	inner := fmt.Errorf("dial failed")
+	err := error(Notice("queue.reconnect", ReconnectGiveUpError{Attempts: 3, Elapsed: time.Second, Inner: inner}))
+
+	var ge ReconnectGiveUpError
+	assert.True(t, errors.As(err, &ge))
+	assert.Equal(t, 3, ge.Attempts)
+	assert.Equal(t, "uof reconnect gave up after 3 attempts in 1s, inner: dial failed", ge.Error())
+	assert.False(t, errors.As(err, &ReconnectError{}))
+
+	err = Notice("queue.reconnect", ReconnectError{Attempt: 2, Inner: inner})
+	var re ReconnectError
+	assert.True(t, errors.As(err, &re))
+	assert.Equal(t, 2, re.Attempt)
+	assert.Equal(t, inner, errors.Unwrap(re))
+}
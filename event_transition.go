@@ -0,0 +1,136 @@
+package uof
+
+// EventTransitionType classifies change of the event lifecycle status.
+type EventTransitionType int8
+
+const (
+	EventTransitionStatusChange EventTransitionType = iota // any other status change
+	EventTransitionKickoff                                 // not started (or delayed) to live
+	EventTransitionPeriodChange                            // match status changed while live
+	EventTransitionSuspended
+	EventTransitionResumed
+	EventTransitionEnded
+	EventTransitionClosed
+	EventTransitionCancelled
+	EventTransitionPostponed
+	EventTransitionAbandoned
+)
+
+func (t EventTransitionType) String() string {
+	switch t {
+	case EventTransitionKickoff:
+		return "kickoff"
+	case EventTransitionPeriodChange:
+		return "period_change"
+	case EventTransitionSuspended:
+		return "suspended"
+	case EventTransitionResumed:
+		return "resumed"
+	case EventTransitionEnded:
+		return "ended"
+	case EventTransitionClosed:
+		return "closed"
+	case EventTransitionCancelled:
+		return "cancelled"
+	case EventTransitionPostponed:
+		return "postponed"
+	case EventTransitionAbandoned:
+		return "abandoned"
+	default:
+		return "status_change"
+	}
+}
+
+// EventState is the last known lifecycle state of the event.
+type EventState struct {
+	EventID     int         `json:"eventID"`
+	EventURN    URN         `json:"eventURN"`
+	Producer    Producer    `json:"producer"`
+	Status      EventStatus `json:"status"`
+	MatchStatus *int        `json:"matchStatus,omitempty"`
+	HomeScore   *int        `json:"homeScore,omitempty"`
+	AwayScore   *int        `json:"awayScore,omitempty"`
+	Clock       *Clock      `json:"clock,omitempty"`
+	Timestamp   int         `json:"timestamp"` // of the last message which changed the state
+}
+
+// Live reports whether event is in play; live, suspended or interrupted.
+func (s EventState) Live() bool {
+	switch s.Status {
+	case EventStatusLive, EventStatusSuspended, EventStatusInterrupted:
+		return true
+	}
+	return false
+}
+
+// EventTransition is change of the event lifecycle state.
+// Invalid is set when the transition is not expected by the lifecycle (for
+// example from closed back to live), state is changed anyway.
+type EventTransition struct {
+	Type            EventTransitionType `json:"type"`
+	From            EventStatus         `json:"from"`
+	To              EventStatus         `json:"to"`
+	MatchStatusFrom *int                `json:"matchStatusFrom,omitempty"`
+	Invalid         bool                `json:"invalid,omitempty"`
+	State           EventState          `json:"state"`
+}
+
+// validTransitions from each status, staying in the same status is always valid
+var validTransitions = map[EventStatus][]EventStatus{
+	EventStatusNotStarted:  {EventStatusLive, EventStatusEnded, EventStatusClosed, EventStatusCancelled, EventStatusDelayed, EventStatusPostponed, EventStatusAbandoned},
+	EventStatusDelayed:     {EventStatusNotStarted, EventStatusLive, EventStatusCancelled, EventStatusPostponed, EventStatusAbandoned},
+	EventStatusPostponed:   {EventStatusNotStarted, EventStatusCancelled},
+	EventStatusLive:        {EventStatusSuspended, EventStatusInterrupted, EventStatusEnded, EventStatusClosed, EventStatusAbandoned},
+	EventStatusSuspended:   {EventStatusLive, EventStatusEnded, EventStatusClosed, EventStatusAbandoned},
+	EventStatusInterrupted: {EventStatusLive, EventStatusEnded, EventStatusClosed, EventStatusAbandoned},
+	EventStatusEnded:       {EventStatusClosed},
+	EventStatusAbandoned:   {EventStatusClosed},
+}
+
+// ValidTransition reports whether event can move from status to status.
+func ValidTransition(from, to EventStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, s := range validTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEventTransition classifies change of the event state.
+func NewEventTransition(prev, cur EventState) EventTransition {
+	t := EventTransition{
+		From:            prev.Status,
+		To:              cur.Status,
+		MatchStatusFrom: prev.MatchStatus,
+		Invalid:         !ValidTransition(prev.Status, cur.Status),
+		State:           cur,
+	}
+	switch cur.Status {
+	case EventStatusLive:
+		switch prev.Status {
+		case EventStatusNotStarted, EventStatusDelayed:
+			t.Type = EventTransitionKickoff
+		case EventStatusSuspended, EventStatusInterrupted:
+			t.Type = EventTransitionResumed
+		case EventStatusLive:
+			t.Type = EventTransitionPeriodChange
+		}
+	case EventStatusSuspended, EventStatusInterrupted:
+		t.Type = EventTransitionSuspended
+	case EventStatusEnded:
+		t.Type = EventTransitionEnded
+	case EventStatusClosed:
+		t.Type = EventTransitionClosed
+	case EventStatusCancelled:
+		t.Type = EventTransitionCancelled
+	case EventStatusPostponed:
+		t.Type = EventTransitionPostponed
+	case EventStatusAbandoned:
+		t.Type = EventTransitionAbandoned
+	}
+	return t
+}
@@ -3,6 +3,8 @@ package uof
 import (
 	"encoding/xml"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,6 +29,8 @@ type Fixture struct {
 	Scheduled          time.Time `xml:"scheduled,attr,omitempty" json:"scheduled,omitempty"`
 	ScheduledEnd       time.Time `xml:"scheduled_end,attr,omitempty" json:"scheduledEnd,omitempty"`
 	ReplacedBy         string    `xml:"replaced_by,attr,omitempty" json:"replacedBy,omitempty"`
+	// GeneratedAt of the api response with the fixture
+	GeneratedAt time.Time `xml:"-" json:"generatedAt,omitempty"`
 
 	Sport      Sport      `xml:"sport" json:"sport"`
 	Category   Category   `xml:"category" json:"category"`
@@ -98,8 +102,9 @@ type Competitor struct {
 	Country      string             `xml:"country,attr,omitempty" json:"country,omitempty"`
 	CountryCode  string             `xml:"country_code,attr,omitempty" json:"countryCode,omitempty"`
 	Virtual      bool               `xml:"virtual,attr,omitempty" json:"virtual,omitempty"`
+	Gender       string             `xml:"gender,attr,omitempty" json:"gender,omitempty"`
 	Players      []CompetitorPlayer `xml:"players>player,omitempty" json:"players,omitempty"`
-	//ReferenceIDs CompetitorReferenceIDs `xml:"reference_ids,omitempty" json:"referenceIDs,omitempty"`
+	ReferenceIDs []ReferenceID      `xml:"reference_ids>reference_id,omitempty" json:"referenceIDs,omitempty"`
 }
 
 type CompetitorPlayer struct {
@@ -203,47 +208,123 @@ type SportEvent struct {
 }
 
 type Summary struct {
-	SportEvent         Fixture             `xml:"sport_event" json:"sportEvent"`
-	GeneratedAt        time.Time           `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
-	SummaryEventStatus *SummaryEventStatus `xml:"sport_event_status,omitempty" json:"summaryEventStatus,omitempty"`
+	SportEvent           Fixture               `xml:"sport_event" json:"sportEvent"`
+	GeneratedAt          time.Time             `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
+	SummaryEventStatus   *SummaryEventStatus   `xml:"sport_event_status,omitempty" json:"summaryEventStatus,omitempty"`
+	SportEventConditions *SportEventConditions `xml:"sport_event_conditions,omitempty" json:"sportEventConditions,omitempty"`
+	Statistics           *SummaryStatistics    `xml:"statistics,omitempty" json:"statistics,omitempty"`
+}
+
+// EventChange is entry in the list of fixture or result changes.
+type EventChange struct {
+	EventID    int       `json:"eventID"`
+	EventURN   URN       `xml:"sport_event_id,attr" json:"eventURN"`
+	UpdateTime time.Time `xml:"update_time,attr" json:"updateTime"`
 }
 
 type MatchTimeline struct {
-	SportEvent  Fixture   `xml:"sport_event" json:"sportEvent"`
-	GeneratedAt time.Time `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
-	//SportEventStatus SportEventStatus `xml:"sport_event_status" json:"sportEventStatus,omitempty"`
-	Events []TimelineEvent `xml:"timeline>event" json:"events,omitempty"`
-	//SportEventConditions struct {
-	//	Text  string `xml:",chardata"`
-	//	Venue struct {
-	//		Text           string `xml:",chardata"`
-	//		ID             string `xml:"id,attr"`
-	//		Name           string `xml:"name,attr"`
-	//		Capacity       string `xml:"capacity,attr"`
-	//		CityName       string `xml:"city_name,attr"`
-	//		CountryName    string `xml:"country_name,attr"`
-	//		MapCoordinates string `xml:"map_coordinates,attr"`
-	//		CountryCode    string `xml:"country_code,attr"`
-	//	} `xml:"venue"`
-	//} `xml:"sport_event_conditions"`
+	SportEvent           Fixture               `xml:"sport_event" json:"sportEvent"`
+	GeneratedAt          time.Time             `xml:"generated_at,attr,omitempty" json:"generatedAt,omitempty"`
+	SportEventStatus     *SummaryEventStatus   `xml:"sport_event_status,omitempty" json:"sportEventStatus,omitempty"`
+	SportEventConditions *SportEventConditions `xml:"sport_event_conditions,omitempty" json:"sportEventConditions,omitempty"`
+	Events               []TimelineEvent       `xml:"timeline>event" json:"events,omitempty"`
+}
+
+type SportEventConditions struct {
+	Venue *Venue `xml:"venue,omitempty" json:"venue,omitempty"`
 }
 
 type TimelineEvent struct {
-	ID              string     `xml:"id,attr"  json:"id,omitempty"`
-	Type            string     `xml:"type,attr" json:"type,omitempty"`
-	Time            time.Time  `xml:"time,attr" json:"time,omitempty"`
-	Period          string     `xml:"period,attr" json:"period,omitempty"`
-	PeriodName      string     `xml:"period_name,attr" json:"periodName,omitempty"`
-	MatchStatusCode string     `xml:"match_status_code,attr" json:"matchStatusCode,omitempty"`
-	MatchTime       string     `xml:"match_time,attr" json:"matchTime,omitempty"`
-	MatchClock      string     `xml:"match_clock,attr" json:"matchClock,omitempty"`
-	Team            string     `xml:"team,attr" json:"team,omitempty"`
-	X               string     `xml:"x,attr" json:"x,omitempty"`
-	Y               string     `xml:"y,attr" json:"y,omitempty"`
-	HomeScore       string     `xml:"home_score,attr" json:"homeScore,omitempty"`
-	AwayScore       string     `xml:"away_score,attr" json:"awayScore,omitempty"`
-	GoalScorer      GoalScorer `xml:"goal_scorer" json:"goalScorer,omitempty"`
-	Assist          Assist     `xml:"assist" json:"assist,omitempty"`
+	ID              string            `xml:"id,attr"  json:"id,omitempty"`
+	Type            string            `xml:"type,attr" json:"type,omitempty"`
+	Kind            TimelineEventKind `json:"kind"`
+	Time            time.Time         `xml:"time,attr" json:"time,omitempty"`
+	Period          string            `xml:"period,attr" json:"period,omitempty"`
+	PeriodName      string            `xml:"period_name,attr" json:"periodName,omitempty"`
+	MatchStatusCode string            `xml:"match_status_code,attr" json:"matchStatusCode,omitempty"`
+	MatchTime       string            `xml:"match_time,attr" json:"matchTime,omitempty"`
+	MatchClock      string            `xml:"match_clock,attr" json:"matchClock,omitempty"`
+	Team            string            `xml:"team,attr" json:"team,omitempty"`
+	X               string            `xml:"x,attr" json:"x,omitempty"`
+	Y               string            `xml:"y,attr" json:"y,omitempty"`
+	HomeScore       string            `xml:"home_score,attr" json:"homeScore,omitempty"`
+	AwayScore       string            `xml:"away_score,attr" json:"awayScore,omitempty"`
+	GoalScorer      GoalScorer        `xml:"goal_scorer" json:"goalScorer,omitempty"`
+	Assist          Assist            `xml:"assist" json:"assist,omitempty"`
+	Player          *TimelinePlayer   `xml:"player" json:"player,omitempty"`
+	PlayerIn        *TimelinePlayer   `xml:"player_in" json:"playerIn,omitempty"`
+	PlayerOut       *TimelinePlayer   `xml:"player_out" json:"playerOut,omitempty"`
+}
+
+type TimelinePlayer struct {
+	ID   string `xml:"id,attr" json:"id,omitempty"`
+	Name string `xml:"name,attr" json:"name,omitempty"`
+}
+
+func (t *TimelineEvent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type T TimelineEvent
+	if err := d.DecodeElement((*T)(t), &start); err != nil {
+		return err
+	}
+	t.Kind = toTimelineEventKind(t.Type)
+	return nil
+}
+
+// EventID returns ID of the timeline event as a number.
+func (t TimelineEvent) EventID() int {
+	id, _ := strconv.Atoi(t.ID)
+	return id
+}
+
+// MatchStatus returns match status code, nil if not set.
+func (t TimelineEvent) MatchStatus() *int {
+	return atoiPtr(t.MatchStatusCode)
+}
+
+// Minute returns minute of the match and stoppage time minute, match time
+// "45+2" is minute 45 and stoppage 2. Ok is false if match time is not set.
+func (t TimelineEvent) Minute() (minute, stoppage int, ok bool) {
+	p := strings.SplitN(t.MatchTime, "+", 2)
+	minute, err := strconv.Atoi(strings.TrimSpace(p[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	if len(p) == 2 {
+		stoppage, _ = strconv.Atoi(strings.TrimSpace(p[1]))
+	}
+	return minute, stoppage, true
+}
+
+// TeamSide returns team of the event, nil if event is not related to a team.
+func (t TimelineEvent) TeamSide() *Team {
+	var v Team
+	switch t.Team {
+	case "home":
+		v = TeamHome
+	case "away":
+		v = TeamAway
+	default:
+		return nil
+	}
+	return &v
+}
+
+// Position returns x and y coordinates of the event on the field.
+func (t TimelineEvent) Position() (x, y *int) {
+	return atoiPtr(t.X), atoiPtr(t.Y)
+}
+
+// Score returns home and away score after the event.
+func (t TimelineEvent) Score() (home, away *int) {
+	return atoiPtr(t.HomeScore), atoiPtr(t.AwayScore)
+}
+
+func atoiPtr(s string) *int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &v
 }
 
 type GoalScorer struct {
@@ -257,47 +338,31 @@ type Assist struct {
 	Type string `xml:"type,attr" json:"type,omitempty"`
 }
 
-//type Statistics struct {
-//	Text   string `xml:",chardata"`
-//	Totals struct {
-//		Text  string `xml:",chardata"`
-//		Teams struct {
-//			Text string `xml:",chardata"`
-//			Team []struct {
-//				Text       string `xml:",chardata"`
-//				ID         string `xml:"id,attr"`
-//				Name       string `xml:"name,attr"`
-//				Statistics struct {
-//					Text        string `xml:",chardata"`
-//					Cards       string `xml:"cards,attr"`
-//					CornerKicks string `xml:"corner_kicks,attr"`
-//					YellowCards string `xml:"yellow_cards,attr"`
-//				} `xml:"statistics"`
-//			} `xml:"team"`
-//		} `xml:"teams"`
-//	} `xml:"totals"`
-//	Periods struct {
-//		Text   string `xml:",chardata"`
-//		Period []struct {
-//			Text  string `xml:",chardata"`
-//			Name  string `xml:"name,attr"`
-//			Teams struct {
-//				Text string `xml:",chardata"`
-//				Team []struct {
-//					Text       string `xml:",chardata"`
-//					ID         string `xml:"id,attr"`
-//					Name       string `xml:"name,attr"`
-//					Statistics struct {
-//						Text        string `xml:",chardata"`
-//						Cards       string `xml:"cards,attr"`
-//						CornerKicks string `xml:"corner_kicks,attr"`
-//						YellowCards string `xml:"yellow_cards,attr"`
-//					} `xml:"statistics"`
-//				} `xml:"team"`
-//			} `xml:"teams"`
-//		} `xml:"period"`
-//	} `xml:"periods"`
-//}
+// SummaryStatistics of the match, totals and for each period.
+type SummaryStatistics struct {
+	Totals  []TeamStatistics   `xml:"totals>teams>team" json:"totals,omitempty"`
+	Periods []PeriodStatistics `xml:"periods>period" json:"periods,omitempty"`
+}
+
+type PeriodStatistics struct {
+	Name  string           `xml:"name,attr" json:"name,omitempty"`
+	Teams []TeamStatistics `xml:"teams>team" json:"teams,omitempty"`
+}
+
+type TeamStatistics struct {
+	URN        URN                  `xml:"id,attr" json:"urn"`
+	Name       string               `xml:"name,attr" json:"name,omitempty"`
+	Qualifier  string               `xml:"qualifier,attr,omitempty" json:"qualifier,omitempty"`
+	Statistics TeamStatisticsValues `xml:"statistics" json:"statistics"`
+}
+
+type TeamStatisticsValues struct {
+	Cards          *int `xml:"cards,attr" json:"cards,omitempty"`
+	YellowCards    *int `xml:"yellow_cards,attr" json:"yellowCards,omitempty"`
+	RedCards       *int `xml:"red_cards,attr" json:"redCards,omitempty"`
+	YellowRedCards *int `xml:"yellow_red_cards,attr" json:"yellowRedCards,omitempty"`
+	CornerKicks    *int `xml:"corner_kicks,attr" json:"cornerKicks,omitempty"`
+}
 
 // slici na sport_event_status ali statusi nisu int nego string
 type SummaryEventStatus struct {
@@ -306,6 +371,8 @@ type SummaryEventStatus struct {
 	HomeScore    *int          `xml:"home_score,attr,omitempty" json:"homeScore,omitempty"`
 	AwayScore    *int          `xml:"away_score,attr,omitempty" json:"awayScore,omitempty"`
 	PeriodScores []PeriodScore `xml:"period_scores>period_score,omitempty" json:"periodScores,omitempty"`
+	Results      []Result      `xml:"results>result,omitempty" json:"results,omitempty"`
+	WinnerURN    URN           `xml:"winner_id,attr,omitempty" json:"winnerURN,omitempty"`
 }
 
 func (f *Fixture) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -324,11 +391,13 @@ func (f *Fixture) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		return err
 	}
 	f.ID = overlay.URN.EventID()
-	f.Sport = overlay.Tournament.Sport
-	f.Category = overlay.Tournament.Category
-	f.Tournament.ID = overlay.Tournament.URN.ID()
-	f.Tournament.URN = overlay.Tournament.URN
-	f.Tournament.Name = overlay.Tournament.Name
+	if overlay.Tournament != nil {
+		f.Sport = overlay.Tournament.Sport
+		f.Category = overlay.Tournament.Category
+		f.Tournament.ID = overlay.Tournament.URN.ID()
+		f.Tournament.URN = overlay.Tournament.URN
+		f.Tournament.Name = overlay.Tournament.Name
+	}
 
 	for _, c := range f.Competitors {
 		if c.Qualifier == "home" {
@@ -0,0 +1,153 @@
+package uof
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FixtureField is the fixture attribute tracked for changes.
+type FixtureField int8
+
+const (
+	FixtureFieldStartTime FixtureField = iota
+	FixtureFieldStatus
+	FixtureFieldCompetitors
+	FixtureFieldVenue
+	FixtureFieldTvChannels
+	FixtureFieldLiveodds
+	FixtureFieldReplacedBy
+)
+
+func (f FixtureField) String() string {
+	switch f {
+	case FixtureFieldStartTime:
+		return "start_time"
+	case FixtureFieldStatus:
+		return "status"
+	case FixtureFieldCompetitors:
+		return "competitors"
+	case FixtureFieldVenue:
+		return "venue"
+	case FixtureFieldTvChannels:
+		return "tv_channels"
+	case FixtureFieldLiveodds:
+		return "liveodds"
+	case FixtureFieldReplacedBy:
+		return "replaced_by"
+	default:
+		return InvalidName
+	}
+}
+
+// FixtureFieldChange is the change of one fixture attribute. From and To are
+// string representations of the attribute, empty when not set. Start time is
+// in RFC3339 format, competitors and tv channels are comma separated lists of
+// urns and names.
+type FixtureFieldChange struct {
+	Field FixtureField `json:"field"`
+	From  string       `json:"from,omitempty"`
+	To    string       `json:"to,omitempty"`
+}
+
+// FixtureDiff is the difference between two versions of the event fixture
+// in the same language. Fixture is the current version.
+type FixtureDiff struct {
+	EventID  int                  `json:"eventID"`
+	EventURN URN                  `json:"eventURN"`
+	Lang     Lang                 `json:"lang"`
+	Changes  []FixtureFieldChange `json:"changes"`
+	Fixture  Fixture              `json:"fixture"`
+}
+
+// Changed reports whether field is among the changes.
+func (d FixtureDiff) Changed(f FixtureField) bool {
+	_, ok := d.Change(f)
+	return ok
+}
+
+// Change of the field, false if the field is not changed.
+func (d FixtureDiff) Change(f FixtureField) (FixtureFieldChange, bool) {
+	for _, c := range d.Changes {
+		if c.Field == f {
+			return c, true
+		}
+	}
+	return FixtureFieldChange{}, false
+}
+
+// NewFixtureDiff compares two versions of the fixture. Returns false when
+// none of the tracked fields is changed.
+//
+// Start time is StartTime, or Scheduled when StartTime is not set. Fixtures
+// from the schedule (preload) have only scheduled time and no tv channels or
+// venue; fields missing in such previous version are not compared.
+func NewFixtureDiff(lang Lang, prev, cur Fixture) (FixtureDiff, bool) {
+	d := FixtureDiff{
+		EventID:  cur.ID,
+		EventURN: cur.URN,
+		Lang:     lang,
+		Fixture:  cur,
+	}
+	add := func(f FixtureField, from, to string) {
+		if from != to {
+			d.Changes = append(d.Changes, FixtureFieldChange{Field: f, From: from, To: to})
+		}
+	}
+	partial := fromSchedule(prev)
+	add(FixtureFieldStartTime, fixtureTime(fixtureStart(prev)), fixtureTime(fixtureStart(cur)))
+	add(FixtureFieldStatus, prev.Status, cur.Status)
+	add(FixtureFieldCompetitors, fixtureCompetitors(prev), fixtureCompetitors(cur))
+	if !partial || prev.Venue != (Venue{}) {
+		add(FixtureFieldVenue, fixtureVenue(prev.Venue), fixtureVenue(cur.Venue))
+	}
+	if !partial || len(prev.TvChannels) > 0 {
+		add(FixtureFieldTvChannels, fixtureTvChannels(prev), fixtureTvChannels(cur))
+	}
+	add(FixtureFieldLiveodds, prev.Liveodds, cur.Liveodds)
+	add(FixtureFieldReplacedBy, prev.ReplacedBy, cur.ReplacedBy)
+	return d, len(d.Changes) > 0
+}
+
+// fromSchedule reports whether fixture comes from the schedule, which sets
+// only scheduled time
+func fromSchedule(x Fixture) bool {
+	return x.StartTime.IsZero() && !x.Scheduled.IsZero()
+}
+
+func fixtureStart(x Fixture) time.Time {
+	if !x.StartTime.IsZero() {
+		return x.StartTime
+	}
+	return x.Scheduled
+}
+
+func fixtureTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func fixtureCompetitors(x Fixture) string {
+	var s []string
+	for _, c := range x.Competitors {
+		s = append(s, string(c.URN))
+	}
+	return strings.Join(s, ",")
+}
+
+func fixtureTvChannels(x Fixture) string {
+	var s []string
+	for _, c := range x.TvChannels {
+		s = append(s, c.Name)
+	}
+	return strings.Join(s, ",")
+}
+
+func fixtureVenue(v Venue) string {
+	if v.ID == 0 {
+		return v.Name
+	}
+	return "sr:venue:" + strconv.Itoa(v.ID)
+}
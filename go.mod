@@ -5,7 +5,7 @@ go 1.13
 require (
 	github.com/cenkalti/backoff/v3 v3.0.0
 	github.com/hashicorp/go-retryablehttp v0.6.2
-	github.com/pkg/errors v0.8.1
+	github.com/klauspost/compress v1.11.13
 	github.com/streadway/amqp v0.0.0-20190827072141-edfb9018d271
 	github.com/stretchr/testify v1.3.0
 )
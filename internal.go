@@ -25,6 +25,32 @@ func (c Connection) TLSVersionToString() string {
 	return "unknown"
 }
 
+// ConnectionStats is periodic throughput report of the queue connection.
+// Rates are calculated over the Interval since the previous report, counters
+// (ParseErrors, Reconnects) are totals since the start.
+type ConnectionStats struct {
+	Timestamp         int     `json:"timestamp,omitempty"`
+	Interval          int     `json:"interval,omitempty"` // report interval in milliseconds
+	Messages          int     `json:"messages"`
+	Bytes             int     `json:"bytes"`
+	MessagesPerSecond float64 `json:"messagesPerSecond"`
+	BytesPerSecond    float64 `json:"bytesPerSecond"`
+	ParseErrors       int     `json:"parseErrors"`
+	SinceLastDelivery int     `json:"sinceLastDelivery"` // in milliseconds, 0 if nothing is delivered yet
+	Reconnects        int     `json:"reconnects"`
+}
+
+// SlowConsumer is reported when consumer has not received any message for
+// longer than the threshold while there are messages waiting for it.
+type SlowConsumer struct {
+	Timestamp int    `json:"timestamp,omitempty"`
+	Consumer  string `json:"consumer,omitempty"`
+	Pending   int    `json:"pending"` // messages waiting in the consumer queue
+	Stalled   int    `json:"stalled"` // since the last received message, in milliseconds
+	Dropped   int    `json:"dropped"` // total superseded messages dropped
+	Spilled   int    `json:"spilled"` // total messages spilled to disk
+}
+
 type ConnectionStatus int8
 
 const (
@@ -1,5 +1,11 @@
 package uof
 
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
 type MatchStatusDescriptions []MatchStatus
 
 type MatchStatus struct {
@@ -17,3 +23,169 @@ type MatchStatusSports struct {
 type MatchStatusSport struct {
 	ID string `xml:"id,attr"`
 }
+
+// MatchPhase groups match statuses of all sports.
+type MatchPhase int8
+
+const (
+	MatchPhaseUnknown MatchPhase = iota
+	MatchPhasePreMatch
+	MatchPhasePeriod
+	MatchPhaseBreak
+	MatchPhaseOvertime
+	MatchPhasePenalties
+	MatchPhaseInterrupted // postponed, delayed, cancelled, interrupted, abandoned
+	MatchPhaseEnded
+)
+
+// codePhase of the match status code, codes without explicit phase are in
+// the period if they have period number
+func codePhase(code, period int) MatchPhase {
+	if p, ok := matchStatusPhases[code]; ok {
+		return p
+	}
+	if period > 0 {
+		return MatchPhasePeriod
+	}
+	return MatchPhaseUnknown
+}
+
+// MatchStatusInfo is decoded match status code.
+type MatchStatusInfo struct {
+	Code         int        `json:"code"`
+	Name         string     `json:"name"`
+	PeriodNumber int        `json:"periodNumber,omitempty"`
+	Phase        MatchPhase `json:"phase"`
+	SportIDs     []int      `json:"sportIDs,omitempty"` // empty for all sports
+}
+
+func (i MatchStatusInfo) forSport(sportID int) bool {
+	if len(i.SportIDs) == 0 || sportID == 0 {
+		return true
+	}
+	for _, id := range i.SportIDs {
+		if id == sportID {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchStatusRegistry maps (sport, code) to the match status description in
+// each language. Starts with embedded english fallback, load api
+// descriptions to replace it.
+type MatchStatusRegistry struct {
+	langs map[Lang]map[int][]MatchStatusInfo
+	sync.RWMutex
+}
+
+// MatchStatuses is default registry used by SportEventStatus helpers.
+var MatchStatuses = NewMatchStatusRegistry()
+
+// NewMatchStatusRegistry creates registry with fallback descriptions.
+func NewMatchStatusRegistry() *MatchStatusRegistry {
+	r := &MatchStatusRegistry{langs: make(map[Lang]map[int][]MatchStatusInfo)}
+	var fallback []struct {
+		ID          int    `json:"id"`
+		Description string `json:"description"`
+		Period      int    `json:"period"`
+	}
+	if err := json.Unmarshal([]byte(matchStatusesJSON), &fallback); err != nil {
+		panic(err)
+	}
+	codes := make(map[int][]MatchStatusInfo)
+	for _, f := range fallback {
+		codes[f.ID] = []MatchStatusInfo{{Code: f.ID, Name: f.Description, PeriodNumber: f.Period, Phase: codePhase(f.ID, f.Period)}}
+	}
+	r.langs[LangEN] = codes
+	return r
+}
+
+// Load replaces descriptions for the language with those from the api.
+func (r *MatchStatusRegistry) Load(lang Lang, ds MatchStatusDescriptions) {
+	codes := make(map[int][]MatchStatusInfo)
+	for _, d := range ds {
+		code, err := strconv.Atoi(d.ID)
+		if err != nil {
+			continue
+		}
+		period, _ := strconv.Atoi(d.PeriodNumber)
+		i := MatchStatusInfo{Code: code, Name: d.Description, PeriodNumber: period}
+		if !d.Sports.All {
+			for _, s := range d.Sports.Sport {
+				i.SportIDs = append(i.SportIDs, URN(s.ID).ID())
+			}
+		}
+		i.Phase = codePhase(code, period)
+		codes[code] = append(codes[code], i)
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.langs[lang] = codes
+}
+
+// Lookup match status of the sport. Zero sportID matches any sport. Falls back
+// to english if language is not loaded.
+func (r *MatchStatusRegistry) Lookup(lang Lang, sportID, code int) (MatchStatusInfo, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	codes, ok := r.langs[lang]
+	if !ok {
+		codes = r.langs[LangEN]
+	}
+	for _, i := range codes[code] {
+		if i.forSport(sportID) {
+			return i, true
+		}
+	}
+	return MatchStatusInfo{Code: code}, false
+}
+
+// Phase of the match in the sport.
+func (s SportEventStatus) Phase(sportID int) MatchPhase {
+	if s.MatchStatus == nil {
+		return MatchPhaseUnknown
+	}
+	i, _ := MatchStatuses.Lookup(LangEN, sportID, *s.MatchStatus)
+	return i.Phase
+}
+
+// IsBreak reports whether match is in the break between periods.
+func (s SportEventStatus) IsBreak(sportID int) bool {
+	return s.Phase(sportID) == MatchPhaseBreak
+}
+
+// IsOvertime reports whether match is in overtime (extra time) or penalties.
+func (s SportEventStatus) IsOvertime(sportID int) bool {
+	p := s.Phase(sportID)
+	return p == MatchPhaseOvertime || p == MatchPhasePenalties
+}
+
+// PeriodNumber of the current period, 0 if not in regular period.
+func (s SportEventStatus) PeriodNumber(sportID int) int {
+	if s.MatchStatus == nil {
+		return 0
+	}
+	i, _ := MatchStatuses.Lookup(LangEN, sportID, *s.MatchStatus)
+	return i.PeriodNumber
+}
+
+// PeriodName is description of the current match status of the sport in the
+// language.
+func (s SportEventStatus) PeriodName(sportID int, lang Lang) string {
+	if s.MatchStatus == nil {
+		return ""
+	}
+	i, _ := MatchStatuses.Lookup(lang, sportID, *s.MatchStatus)
+	return i.Name
+}
+
+// PeriodName is description of the period match status of the sport in the
+// language.
+func (p PeriodScore) PeriodName(sportID int, lang Lang) string {
+	if p.MatchStatusCode == nil {
+		return ""
+	}
+	i, _ := MatchStatuses.Lookup(lang, sportID, *p.MatchStatusCode)
+	return i.Name
+}
@@ -0,0 +1,106 @@
+package uof
+
+// Fallback english match status descriptions used until they are loaded from
+// the api (/v1/descriptions/en/match_status.xml). Only the most common
+// statuses are listed, all of them are valid for all sports.
+var matchStatusesJSON = `[
+{"id":0,"description":"Not started"},
+{"id":1,"description":"1st period","period":1},
+{"id":2,"description":"2nd period","period":2},
+{"id":3,"description":"3rd period","period":3},
+{"id":4,"description":"4th period","period":4},
+{"id":5,"description":"5th period","period":5},
+{"id":6,"description":"1st half","period":1},
+{"id":7,"description":"2nd half","period":2},
+{"id":8,"description":"1st set","period":1},
+{"id":9,"description":"2nd set","period":2},
+{"id":10,"description":"3rd set","period":3},
+{"id":11,"description":"4th set","period":4},
+{"id":12,"description":"5th set","period":5},
+{"id":13,"description":"1st quarter","period":1},
+{"id":14,"description":"2nd quarter","period":2},
+{"id":15,"description":"3rd quarter","period":3},
+{"id":16,"description":"4th quarter","period":4},
+{"id":17,"description":"Golden set"},
+{"id":20,"description":"Started"},
+{"id":21,"description":"In progress"},
+{"id":22,"description":"About to start"},
+{"id":30,"description":"Pause"},
+{"id":31,"description":"Halftime"},
+{"id":32,"description":"Awaiting extra time"},
+{"id":33,"description":"Extra time halftime"},
+{"id":34,"description":"Awaiting penalties"},
+{"id":40,"description":"Overtime"},
+{"id":41,"description":"1st extra","period":1},
+{"id":42,"description":"2nd extra","period":2},
+{"id":50,"description":"Penalties"},
+{"id":60,"description":"Postponed"},
+{"id":61,"description":"Start delayed"},
+{"id":70,"description":"Cancelled"},
+{"id":80,"description":"Interrupted"},
+{"id":90,"description":"Abandoned"},
+{"id":100,"description":"Ended"},
+{"id":110,"description":"AET"},
+{"id":120,"description":"AP"},
+{"id":301,"description":"First break"},
+{"id":302,"description":"Second break"},
+{"id":303,"description":"Third break"},
+{"id":304,"description":"Fourth break"},
+{"id":305,"description":"Fifth break"},
+{"id":306,"description":"Sixth break"},
+{"id":440,"description":"Sudden death"}
+]`
+
+// matchStatusPhases maps match status codes to the phase, the same for all
+// sports.
+var matchStatusPhases = map[int]MatchPhase{
+	0:   MatchPhasePreMatch,    // not started
+	22:  MatchPhasePreMatch,    // about to start
+	1:   MatchPhasePeriod,      // 1st period
+	2:   MatchPhasePeriod,      // 2nd period
+	3:   MatchPhasePeriod,      // 3rd period
+	4:   MatchPhasePeriod,      // 4th period
+	5:   MatchPhasePeriod,      // 5th period
+	6:   MatchPhasePeriod,      // 1st half
+	7:   MatchPhasePeriod,      // 2nd half
+	8:   MatchPhasePeriod,      // 1st set
+	9:   MatchPhasePeriod,      // 2nd set
+	10:  MatchPhasePeriod,      // 3rd set
+	11:  MatchPhasePeriod,      // 4th set
+	12:  MatchPhasePeriod,      // 5th set
+	13:  MatchPhasePeriod,      // 1st quarter
+	14:  MatchPhasePeriod,      // 2nd quarter
+	15:  MatchPhasePeriod,      // 3rd quarter
+	16:  MatchPhasePeriod,      // 4th quarter
+	17:  MatchPhaseOvertime,    // golden set
+	20:  MatchPhasePeriod,      // started
+	21:  MatchPhasePeriod,      // in progress
+	30:  MatchPhaseBreak,       // pause
+	31:  MatchPhaseBreak,       // halftime
+	32:  MatchPhaseBreak,       // awaiting extra time
+	33:  MatchPhaseBreak,       // extra time halftime
+	34:  MatchPhaseBreak,       // awaiting penalties
+	35:  MatchPhaseBreak,       // awaiting sudden death
+	40:  MatchPhaseOvertime,    // overtime
+	41:  MatchPhaseOvertime,    // 1st extra
+	42:  MatchPhaseOvertime,    // 2nd extra
+	50:  MatchPhasePenalties,   // penalties
+	60:  MatchPhaseInterrupted, // postponed
+	61:  MatchPhaseInterrupted, // start delayed
+	70:  MatchPhaseInterrupted, // cancelled
+	80:  MatchPhaseInterrupted, // interrupted
+	81:  MatchPhaseInterrupted, // suspended
+	90:  MatchPhaseInterrupted, // abandoned
+	91:  MatchPhaseEnded,       // walkover
+	92:  MatchPhaseEnded,       // retired
+	100: MatchPhaseEnded,       // ended
+	110: MatchPhaseEnded,       // AET
+	120: MatchPhaseEnded,       // AP
+	301: MatchPhaseBreak,       // first break
+	302: MatchPhaseBreak,       // second break
+	303: MatchPhaseBreak,       // third break
+	304: MatchPhaseBreak,       // fourth break
+	305: MatchPhaseBreak,       // fifth break
+	306: MatchPhaseBreak,       // sixth break
+	440: MatchPhaseOvertime,    // sudden death
+}
@@ -0,0 +1,80 @@
+package uof
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchStatusRegistry(t *testing.T) {
+	r := NewMatchStatusRegistry()
+	i, ok := r.Lookup(LangDE, 1, 31)
+	assert.True(t, ok)
+	assert.Equal(t, "Halftime", i.Name)
+	assert.Equal(t, MatchPhaseBreak, i.Phase)
+
+	r.Load(LangDE, MatchStatusDescriptions{
+		{ID: "6", Description: "1. Halbzeit", PeriodNumber: "1", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:1"}}}},
+		{ID: "6", Description: "1. Hälfte", PeriodNumber: "1", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:2"}}}},
+	})
+	i, ok = r.Lookup(LangDE, 2, 6)
+	assert.True(t, ok)
+	assert.Equal(t, "1. Hälfte", i.Name)
+	assert.Equal(t, 1, i.PeriodNumber)
+	assert.Equal(t, []int{2}, i.SportIDs)
+	_, ok = r.Lookup(LangDE, 5, 6)
+	assert.False(t, ok)
+	_, ok = r.Lookup(LangDE, 1, 31)
+	assert.False(t, ok)
+
+	// phase is found from the code, regardless of the description language
+	r.Load(LangFR, MatchStatusDescriptions{
+		{ID: "110", Description: "Après prolongation", Sports: MatchStatusSports{All: true}},
+		{ID: "999", Description: "Inconnu", Sports: MatchStatusSports{All: true}},
+	})
+	i, _ = r.Lookup(LangFR, 1, 110)
+	assert.Equal(t, MatchPhaseEnded, i.Phase)
+	i, _ = r.Lookup(LangFR, 1, 999)
+	assert.Equal(t, MatchPhaseUnknown, i.Phase)
+	r.Load(LangEN, MatchStatusDescriptions{
+		{ID: "301", Description: "First break", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:2"}}}},
+		{ID: "301", Description: "Awaiting super over", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:21"}}}},
+		{ID: "7", Description: "2nd half", PeriodNumber: "2", Sports: MatchStatusSports{All: true}},
+	})
+	r.Load(LangDE, MatchStatusDescriptions{
+		{ID: "301", Description: "Erste Pause", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:2"}}}},
+	})
+	i, _ = r.Lookup(LangDE, 2, 301)
+	assert.Equal(t, MatchPhaseBreak, i.Phase)
+	i, _ = r.Lookup(LangEN, 7, 7)
+	assert.Equal(t, MatchPhasePeriod, i.Phase)
+	assert.Equal(t, 2, i.PeriodNumber)
+}
+
+func TestSportEventStatusMatchStatus(t *testing.T) {
+	code := func(c int) *int { return &c }
+	s := SportEventStatus{MatchStatus: code(31)}
+	assert.True(t, s.IsBreak(1))
+	assert.False(t, s.IsOvertime(1))
+	assert.Equal(t, "Halftime", s.PeriodName(1, LangEN))
+
+	s = SportEventStatus{MatchStatus: code(41)}
+	assert.True(t, s.IsOvertime(1))
+	assert.Equal(t, 1, s.PeriodNumber(1))
+	assert.Equal(t, MatchPhaseOvertime, s.Phase(1))
+
+	assert.Equal(t, "2nd half", PeriodScore{MatchStatusCode: code(7)}.PeriodName(1, LangEN))
+	assert.Equal(t, MatchPhaseUnknown, SportEventStatus{}.Phase(1))
+	assert.Equal(t, MatchPhaseUnknown, SportEventStatus{MatchStatus: code(999)}.Phase(1))
+
+	// description of the event sport is used
+	defer func(r *MatchStatusRegistry) { MatchStatuses = r }(MatchStatuses)
+	MatchStatuses = NewMatchStatusRegistry()
+	MatchStatuses.Load(LangEN, MatchStatusDescriptions{
+		{ID: "6", Description: "1st period", PeriodNumber: "1", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:4"}}}},
+		{ID: "6", Description: "1st half", PeriodNumber: "1", Sports: MatchStatusSports{Sport: []MatchStatusSport{{ID: "sr:sport:1"}}}},
+	})
+	s = SportEventStatus{MatchStatus: code(6)}
+	assert.Equal(t, "1st half", s.PeriodName(1, LangEN))
+	assert.Equal(t, "1st period", s.PeriodName(4, LangEN))
+}
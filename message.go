@@ -23,6 +23,7 @@ type Header struct {
 	RequestedAt int             `json:"requestedAt,omitempty"`
 	Producer    Producer        `json:"producer,omitempty"`
 	Timestamp   int             `json:"timestamp,omitempty"`
+	Duplicate   bool            `json:"duplicate,omitempty"` // set by the pipe.Dedup stage
 }
 
 type Body struct {
@@ -41,13 +42,22 @@ type Body struct {
 	Fixture            *Fixture            `json:"fixture,omitempty"`
 	Markets            MarketDescriptions  `json:"markets,omitempty"`
 	Player             *Player             `json:"player,omitempty"`
-	Competitor         *CompetitorPlayer   `json:"competitor,omitempty"`
+	Competitor         *CompetitorProfile  `json:"competitor,omitempty"`
 	Tournament         *FixtureTournament  `json:"tournament,omitempty"`
 	SummaryEventStatus *SummaryEventStatus `json:"summaryEventStatus,omitempty"`
+	Timeline           *MatchTimeline      `json:"timeline,omitempty"`
+	Summary            *Summary            `json:"summary,omitempty"`
+	Catalogue          *Catalogue          `json:"catalogue,omitempty"`
 
 	// sdk status message types
-	Connection *Connection     `json:"connection,omitempty"`
-	Producers  ProducersChange `json:"producerChange,omitempty"`
+	Connection       *Connection       `json:"connection,omitempty"`
+	Producers        ProducersChange   `json:"producerChange,omitempty"`
+	ConnectionStats  *ConnectionStats  `json:"connectionStats,omitempty"`
+	SlowConsumer     *SlowConsumer     `json:"slowConsumer,omitempty"`
+	SettlementChange *SettlementChange `json:"settlementChange,omitempty"`
+	EventTransition  *EventTransition  `json:"eventTransition,omitempty"`
+	FixtureDiff      *FixtureDiff      `json:"fixtureDiff,omitempty"`
+	OddsDiff         *OddsChangeDiff   `json:"oddsDiff,omitempty"`
 }
 
 type Message struct {
@@ -206,6 +216,15 @@ func (m *Message) unpack() error {
 		unmarshal(&s)
 		m.Fixture = &s.SportEvent
 		m.SummaryEventStatus = s.SummaryEventStatus
+		m.Summary = &s
+	case MessageTypeCompetitor:
+		c := CompetitorProfile{}
+		unmarshal(&c)
+		m.Competitor = &c
+	case MessageTypeTimeline:
+		t := MatchTimeline{}
+		unmarshal(&t)
+		m.Timeline = &t
 	case MessageTypeMarkets:
 		md := &MarketsRsp{}
 		unmarshal(md)
@@ -264,7 +283,7 @@ func NewPlayerMessage(lang Lang, player *Player, requestedAt int) *Message {
 	}
 }
 
-func NewCompetitorMessage(lang Lang, competitor *CompetitorPlayer, requestedAt int) *Message {
+func NewCompetitorMessage(lang Lang, competitor *CompetitorProfile, requestedAt int) *Message {
 	return &Message{
 		Header: Header{
 			Type:        MessageTypeCompetitor,
@@ -331,6 +350,100 @@ func NewDetailedConnnectionMessage(status ConnectionStatus, serverName, localAdd
 	}
 }
 
+func NewConnectionStatsMessage(s ConnectionStats) *Message {
+	ts := uniqTimestamp()
+	s.Timestamp = ts
+	return &Message{
+		Header: Header{
+			Type:       MessageTypeConnectionStats,
+			Scope:      MessageScopeSystem,
+			ReceivedAt: ts,
+		},
+		Body: Body{ConnectionStats: &s},
+	}
+}
+
+func NewSlowConsumerMessage(s SlowConsumer) *Message {
+	ts := uniqTimestamp()
+	s.Timestamp = ts
+	return &Message{
+		Header: Header{
+			Type:       MessageTypeSlowConsumer,
+			Scope:      MessageScopeSystem,
+			ReceivedAt: ts,
+		},
+		Body: Body{SlowConsumer: &s},
+	}
+}
+
+func NewSettlementChangeMessage(s SettlementChange) *Message {
+	return newSettlementMessage(MessageTypeSettlementChange, s)
+}
+
+// NewSettlementDiscrepancyMessage has the same body as the settlement change
+// message.
+func NewSettlementDiscrepancyMessage(s SettlementChange) *Message {
+	return newSettlementMessage(MessageTypeSettlementDiscrepancy, s)
+}
+
+func newSettlementMessage(typ MessageType, s SettlementChange) *Message {
+	return &Message{
+		Header: Header{
+			Type:       typ,
+			Scope:      producerScope(s.Producer),
+			EventID:    s.EventID,
+			EventURN:   s.EventURN,
+			Producer:   s.Producer,
+			Timestamp:  s.Timestamp,
+			ReceivedAt: uniqTimestamp(),
+		},
+		Body: Body{SettlementChange: &s},
+	}
+}
+
+func NewEventTransitionMessage(t EventTransition) *Message {
+	return &Message{
+		Header: Header{
+			Type:       MessageTypeEventTransition,
+			Scope:      producerScope(t.State.Producer),
+			EventID:    t.State.EventID,
+			EventURN:   t.State.EventURN,
+			Producer:   t.State.Producer,
+			Timestamp:  t.State.Timestamp,
+			ReceivedAt: uniqTimestamp(),
+		},
+		Body: Body{EventTransition: &t},
+	}
+}
+
+func NewFixtureDiffMessage(d FixtureDiff, requestedAt int) *Message {
+	return &Message{
+		Header: Header{
+			Type:        MessageTypeFixtureDiff,
+			Scope:       producerScope(d.EventURN.Producer()),
+			EventID:     d.EventID,
+			EventURN:    d.EventURN,
+			Lang:        d.Lang,
+			Producer:    d.EventURN.Producer(),
+			ReceivedAt:  uniqTimestamp(),
+			RequestedAt: requestedAt,
+		},
+		Body: Body{FixtureDiff: &d},
+	}
+}
+
+// NewOddsDiffMessage creates odds diff message with the header of the odds
+// change message.
+func (m *Message) NewOddsDiffMessage(d OddsChangeDiff) *Message {
+	c := &Message{
+		Header: m.Header,
+	}
+	c.Type = MessageTypeOddsDiff
+	c.ReceivedAt = uniqTimestamp()
+	c.OddsDiff = &d
+	return c
+}
+
 func NewProducersChangeMessage(pc ProducersChange) *Message {
 	return &Message{
 		Header: Header{
@@ -372,7 +485,35 @@ func NewSummaryMessage(lang Lang, s Summary, requestedAt int) *Message {
 		Body: Body{
 			Fixture:            f,
 			SummaryEventStatus: s.SummaryEventStatus,
+			Summary:            &s,
+		},
+	}
+}
+
+func NewTimelineMessage(lang Lang, t *MatchTimeline, requestedAt int) *Message {
+	f := &t.SportEvent
+	return &Message{
+		Header: Header{
+			Type:        MessageTypeTimeline,
+			EventURN:    f.URN,
+			EventID:     f.ID,
+			Lang:        lang,
+			ReceivedAt:  uniqTimestamp(),
+			RequestedAt: requestedAt,
+		},
+		Body: Body{Timeline: t},
+	}
+}
+
+func NewCatalogueMessage(lang Lang, c *Catalogue, requestedAt int) *Message {
+	return &Message{
+		Header: Header{
+			Type:        MessageTypeCatalogue,
+			Lang:        lang,
+			ReceivedAt:  uniqTimestamp(),
+			RequestedAt: requestedAt,
 		},
+		Body: Body{Catalogue: c},
 	}
 }
 
@@ -29,6 +29,10 @@ type OddsChange struct {
 
 	OddsGenerationProperties *OddsGenerationProperties `xml:"odds_generation_properties,omitempty" json:"oddsGenerationProperties,omitempty"`
 	RequestID                *int                      `xml:"request_id,attr,omitempty" json:"requestID,omitempty"`
+	// Diff to the previous odds change of the event, set by the pipe.OddsDiff
+	// stage. It exists in memory only, stores keep the raw odds change and
+	// the odds diff message which follows it.
+	Diff *OddsChangeDiff `xml:"-" json:"diff,omitempty"`
 }
 
 // Provided by the prematch odds producer only, and contains a few
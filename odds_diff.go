@@ -0,0 +1,114 @@
+package uof
+
+// OddsDirection in which outcome odds moved.
+type OddsDirection int8
+
+const (
+	OddsUnchanged OddsDirection = 0
+	OddsUp        OddsDirection = 1
+	OddsDown      OddsDirection = -1
+)
+
+// OddsChangeDiff lists market lines of the odds change which differ from the
+// previous known state of the event markets. Lines without any change are not
+// listed.
+type OddsChangeDiff struct {
+	Markets []MarketDiff `json:"markets,omitempty"`
+}
+
+// MarketDiff changes of one market line.
+type MarketDiff struct {
+	ID     int `json:"id"`
+	LineID int `json:"lineID"`
+	// Added line was not in the previous state.
+	Added bool `json:"added,omitempty"`
+	// Removed line is deactivated, settled, cancelled or handed over.
+	Removed   bool                `json:"removed,omitempty"`
+	Status    *MarketStatusChange `json:"status,omitempty"`
+	Favourite *BoolChange         `json:"favourite,omitempty"`
+	Outcomes  []OutcomeDiff       `json:"outcomes,omitempty"`
+}
+
+// OutcomeDiff changes of one outcome in the market line.
+type OutcomeDiff struct {
+	ID         int           `json:"id"`
+	PlayerID   int           `json:"playerID,omitempty"`
+	VariantURN URN           `json:"variantURN,omitempty"`
+	OddsFrom   float64       `json:"oddsFrom,omitempty"`
+	OddsTo     float64       `json:"oddsTo,omitempty"`
+	Delta      float64       `json:"delta,omitempty"` // OddsTo - OddsFrom
+	Direction  OddsDirection `json:"direction,omitempty"`
+	Active     *BoolChange   `json:"active,omitempty"`
+}
+
+type MarketStatusChange struct {
+	From MarketStatus `json:"from"`
+	To   MarketStatus `json:"to"`
+}
+
+type BoolChange struct {
+	From bool `json:"from"`
+	To   bool `json:"to"`
+}
+
+// Removed reports whether market line is no longer offered.
+func (m Market) Removed() bool {
+	return m.Status != MarketStatusActive && m.Status != MarketStatusSuspended
+}
+
+// DiffMarket compares market line cur with its previous state prev. Returns
+// false if there is no change.
+func DiffMarket(prev, cur Market) (MarketDiff, bool) {
+	d := MarketDiff{ID: cur.ID, LineID: cur.LineID, Removed: cur.Removed()}
+	if prev.Status != cur.Status {
+		d.Status = &MarketStatusChange{From: prev.Status, To: cur.Status}
+	}
+	if pf, cf := boolValue(prev.Favourite, false), boolValue(cur.Favourite, false); pf != cf {
+		d.Favourite = &BoolChange{From: pf, To: cf}
+	}
+	for _, co := range cur.Outcomes {
+		po, ok := findOutcome(prev.Outcomes, co)
+		if !ok {
+			continue
+		}
+		if od, ok := diffOutcome(po, co); ok {
+			d.Outcomes = append(d.Outcomes, od)
+		}
+	}
+	changed := d.Status != nil || d.Favourite != nil || len(d.Outcomes) > 0
+	return d, changed
+}
+
+func diffOutcome(prev, cur Outcome) (OutcomeDiff, bool) {
+	d := OutcomeDiff{ID: cur.ID, PlayerID: cur.PlayerID, VariantURN: cur.VariantURN}
+	if prev.Odds != nil && cur.Odds != nil && *prev.Odds != *cur.Odds {
+		d.OddsFrom = *prev.Odds
+		d.OddsTo = *cur.Odds
+		d.Delta = d.OddsTo - d.OddsFrom
+		d.Direction = OddsUp
+		if d.Delta < 0 {
+			d.Direction = OddsDown
+		}
+	}
+	// outcome is active if not stated otherwise
+	if pa, ca := boolValue(prev.Active, true), boolValue(cur.Active, true); pa != ca {
+		d.Active = &BoolChange{From: pa, To: ca}
+	}
+	return d, d.Direction != OddsUnchanged || d.Active != nil
+}
+
+func findOutcome(outcomes []Outcome, o Outcome) (Outcome, bool) {
+	for _, e := range outcomes {
+		if e.ID == o.ID && e.PlayerID == o.PlayerID && e.VariantURN == o.VariantURN {
+			return e, true
+		}
+	}
+	return Outcome{}, false
+}
+
+func boolValue(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
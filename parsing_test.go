@@ -289,6 +289,35 @@ func TestPlayerUnknown(t *testing.T) {
 	assert.Equal(t, p, *msg.Player)
 }
 
+func TestCompetitorProfile(t *testing.T) {
+	buf, err := ioutil.ReadFile("./testdata/competitor_profile.xml")
+	assert.Nil(t, err)
+
+	m, err := NewAPIMessage(LangEN, MessageTypeCompetitor, buf)
+	assert.NoError(t, err)
+	cp := m.Competitor
+	assert.NotNil(t, cp)
+
+	c := cp.Competitor
+	assert.Equal(t, 2953, c.ID)
+	assert.Equal(t, "Ajax Amsterdam", c.Name)
+	assert.Equal(t, "NLD", c.CountryCode)
+	assert.Equal(t, "32158", c.Reference("betradar"))
+	assert.True(t, c.URN.IsCompetitor())
+
+	assert.Equal(t, "Johan Cruijff Arena", cp.Venue.Name)
+	assert.Len(t, cp.Jerseys, 2)
+	assert.Equal(t, "home", cp.Jerseys[0].Type)
+	assert.Equal(t, "ffffff", cp.Jerseys[0].Base)
+	assert.True(t, cp.Jerseys[0].Split)
+	assert.Equal(t, 60542, cp.Manager.ID)
+	assert.Equal(t, "ten Hag, Erik", cp.Manager.Name)
+	assert.Len(t, cp.Players, 2)
+	assert.Equal(t, 334637, cp.Players[0].ID)
+	assert.Equal(t, 4, cp.Players[0].JerseyNumber)
+	assert.Equal(t, "Tadic, Dusan", cp.Players[1].Name)
+}
+
 func TestFixture(t *testing.T) {
 	buf, err := ioutil.ReadFile("./testdata/fixture-0.xml")
 	assert.Nil(t, err)
@@ -372,6 +401,95 @@ func TestFixtureTournament(t *testing.T) {
 	pp(ft)
 }
 
+func TestSummary(t *testing.T) {
+	buf, err := ioutil.ReadFile("./testdata/summary-0.xml")
+	assert.Nil(t, err)
+
+	m, err := NewAPIMessage(LangEN, MessageTypeSummary, buf)
+	assert.NoError(t, err)
+	s := m.Summary
+	assert.NotNil(t, s)
+	assert.Equal(t, 18001015, m.Fixture.ID)
+
+	es := s.SummaryEventStatus
+	assert.Equal(t, "closed", es.Status)
+	assert.Equal(t, 3, *es.AwayScore)
+	assert.Len(t, es.PeriodScores, 2)
+	assert.Equal(t, URN("sr:competitor:33"), es.WinnerURN)
+	assert.Equal(t, "Amsterdam", s.SportEventConditions.Venue.CityName)
+
+	st := s.Statistics
+	assert.Len(t, st.Totals, 2)
+	assert.Equal(t, "away", st.Totals[1].Qualifier)
+	assert.Equal(t, 6, *st.Totals[1].Statistics.CornerKicks)
+	assert.Equal(t, 4, *st.Totals[0].Statistics.YellowCards)
+	assert.Len(t, st.Periods, 1)
+	assert.Equal(t, "1st half", st.Periods[0].Name)
+	assert.Nil(t, st.Periods[0].Teams[0].Statistics.RedCards)
+}
+
+func TestTimeline(t *testing.T) {
+	buf, err := ioutil.ReadFile("./testdata/timeline-0.xml")
+	assert.Nil(t, err)
+
+	tl := MatchTimeline{}
+	err = xml.Unmarshal(buf, &tl)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 18001015, tl.SportEvent.ID)
+	assert.Equal(t, "live", tl.SportEventStatus.Status)
+	assert.Equal(t, 2, *tl.SportEventStatus.HomeScore)
+	assert.Equal(t, "Johan Cruijff Arena", tl.SportEventConditions.Venue.Name)
+	assert.Len(t, tl.Events, 5)
+
+	e := tl.Events[1]
+	assert.Equal(t, TimelineEventPeriodStart, e.Kind)
+	assert.Equal(t, "6", e.MatchStatusCode)
+	assert.Equal(t, 6, *e.MatchStatus())
+	assert.Nil(t, e.TeamSide())
+	_, _, ok := e.Minute()
+	assert.False(t, ok)
+
+	e = tl.Events[2]
+	assert.Equal(t, TimelineEventGoal, e.Kind)
+	assert.Equal(t, 1003, e.EventID())
+	assert.Equal(t, "home", e.Team)
+	assert.Equal(t, TeamHome, *e.TeamSide())
+	minute, stoppage, ok := e.Minute()
+	assert.True(t, ok)
+	assert.Equal(t, 5, minute)
+	assert.Equal(t, 0, stoppage)
+	home, away := e.Score()
+	assert.Equal(t, 1, *home)
+	assert.Equal(t, 0, *away)
+	x, y := e.Position()
+	assert.Equal(t, 90, *x)
+	assert.Equal(t, 45, *y)
+	assert.Equal(t, "sr:player:334637", e.GoalScorer.ID)
+
+	e = tl.Events[3]
+	assert.Equal(t, TimelineEventYellowCard, e.Kind)
+	assert.True(t, e.Kind.IsCard())
+	assert.Equal(t, TeamAway, *e.TeamSide())
+	assert.Equal(t, "Alli, Dele", e.Player.Name)
+	home, _ = e.Score()
+	assert.Nil(t, home)
+
+	e = tl.Events[4]
+	assert.Equal(t, TimelineEventSubstitution, e.Kind)
+	assert.Equal(t, "45+2", e.MatchTime)
+	minute, stoppage, _ = e.Minute()
+	assert.Equal(t, 45, minute)
+	assert.Equal(t, 2, stoppage)
+	assert.Equal(t, "sr:player:166117", e.PlayerIn.ID)
+	assert.Equal(t, "sr:player:131394", e.PlayerOut.ID)
+
+	m := NewTimelineMessage(LangEN, &tl, 0)
+	assert.Equal(t, MessageTypeTimeline, m.Type)
+	assert.Equal(t, MessageKindLexicon, m.Type.Kind())
+	assert.Equal(t, 18001015, m.EventID)
+}
+
 func TestBetSettlementToResult(t *testing.T) {
 	data := []struct {
 		result         int
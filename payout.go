@@ -0,0 +1,103 @@
+package uof
+
+// Payout of the settled bet. Win is amount paid for the winning part of the
+// stake (stake included), Refund is returned part of the stake.
+type Payout struct {
+	Win    float64 `json:"win"`
+	Refund float64 `json:"refund"`
+}
+
+// Total amount paid to the customer.
+func (p Payout) Total() float64 {
+	return p.Win + p.Refund
+}
+
+// Settled reports whether the outcome result is known.
+func (o BetSettlementOutcome) Settled() bool {
+	return o.Result != OutcomeResultUnknown
+}
+
+// voidFactor is refunded part of the stake. When outcome has no void factor
+// it is implied by the result.
+func (o BetSettlementOutcome) voidFactor() float64 {
+	if o.VoidFactor > 0 {
+		return o.VoidFactor
+	}
+	switch o.Result {
+	case OutcomeResultVoid:
+		return 1
+	case OutcomeResultHalfWin, OutcomeResultHalfLose:
+		return 0.5
+	}
+	return 0
+}
+
+// factors of the stake which are won (paid with odds) and refunded. Void
+// factor part of the stake is refunded, the rest is won or lost by the
+// result.
+func (o BetSettlementOutcome) factors() (win, refund float64) {
+	if !o.Settled() {
+		return 0, 0
+	}
+	refund = o.voidFactor()
+	switch o.Result {
+	case OutcomeResultWin, OutcomeResultHalfWin, OutcomeResultWinWithDeadHead:
+		win = 1 - refund
+		if o.DeadHeatFactor > 0 { // winning part is reduced by the dead heat factor, rest is lost
+			win *= o.DeadHeatFactor
+		}
+	}
+	return win, refund
+}
+
+// Payout for the single bet with stake placed on the outcome with odds.
+// Returns zero payout for the lost or not settled outcome.
+func (o BetSettlementOutcome) Payout(stake, odds float64) Payout {
+	win, refund := o.factors()
+	return Payout{
+		Win:    stake * win * odds,
+		Refund: stake * refund,
+	}
+}
+
+// BetLeg is one selection of the accumulator bet.
+type BetLeg struct {
+	Odds    float64
+	Outcome BetSettlementOutcome
+}
+
+// AccumulatorPayout for the multi-leg bet where the return of each leg is
+// stake for the next one.
+//
+// Each leg multiplies running stake by its return: won part of the stake
+// times odds plus refunded (void factor) part. Won part is the rest of the
+// stake for win, reduced by the dead heat factor for dead heat and none for
+// lose; void leg (factor 1) is removed from the bet. If any leg is lost bet is
+// lost, otherwise returns false until all legs are settled. When no leg has
+// winning part (all are void or half lose) payout is refund.
+func AccumulatorPayout(stake float64, legs []BetLeg) (Payout, bool) {
+	total := stake
+	settled := true
+	won := false
+	for _, l := range legs {
+		if !l.Outcome.Settled() {
+			settled = false
+			continue
+		}
+		win, refund := l.Outcome.factors()
+		if win == 0 && refund == 0 {
+			return Payout{}, true
+		}
+		if win > 0 {
+			won = true
+		}
+		total *= win*l.Odds + refund
+	}
+	if !settled {
+		return Payout{}, false
+	}
+	if !won {
+		return Payout{Refund: total}, true
+	}
+	return Payout{Win: total}, true
+}
@@ -0,0 +1,70 @@
+package uof
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayout(t *testing.T) {
+	data := []struct {
+		outcome BetSettlementOutcome
+		payout  Payout
+	}{
+		{BetSettlementOutcome{Result: OutcomeResultWin}, Payout{Win: 25}},
+		{BetSettlementOutcome{Result: OutcomeResultLose}, Payout{}},
+		{BetSettlementOutcome{Result: OutcomeResultVoid, VoidFactor: 1}, Payout{Refund: 10}},
+		{BetSettlementOutcome{Result: OutcomeResultHalfWin, VoidFactor: 0.5}, Payout{Win: 12.5, Refund: 5}},
+		{BetSettlementOutcome{Result: OutcomeResultHalfLose, VoidFactor: 0.5}, Payout{Refund: 5}},
+		{BetSettlementOutcome{Result: OutcomeResultWinWithDeadHead, DeadHeatFactor: 0.25}, Payout{Win: 6.25}},
+		{BetSettlementOutcome{Result: OutcomeResultHalfWin, DeadHeatFactor: 0.5, VoidFactor: 0.5}, Payout{Win: 6.25, Refund: 5}},
+		{BetSettlementOutcome{Result: OutcomeResultHalfWin, VoidFactor: 0.25}, Payout{Win: 18.75, Refund: 2.5}},
+		{BetSettlementOutcome{Result: OutcomeResultHalfWin}, Payout{Win: 12.5, Refund: 5}},
+		{BetSettlementOutcome{Result: OutcomeResultVoid}, Payout{Refund: 10}},
+		{BetSettlementOutcome{Result: OutcomeResultUnknown}, Payout{}},
+	}
+	for _, d := range data {
+		p := d.outcome.Payout(10, 2.5)
+		assert.InDelta(t, d.payout.Win, p.Win, 1e-9)
+		assert.InDelta(t, d.payout.Refund, p.Refund, 1e-9)
+	}
+}
+
+func TestPayoutDeadHeatHalfWin(t *testing.T) {
+	var o BetSettlementOutcome
+	err := xml.Unmarshal([]byte(`<outcome id="1" result="1" void_factor="0.5" dead_heat_factor="0.5"/>`), &o)
+	assert.NoError(t, err)
+	assert.Equal(t, OutcomeResultHalfWin, o.Result)
+	p := o.Payout(10, 2.5)
+	assert.InDelta(t, 6.25, p.Win, 1e-9)
+	assert.InDelta(t, 5, p.Refund, 1e-9)
+}
+
+func TestAccumulatorPayout(t *testing.T) {
+	leg := func(odds float64, r OutcomeResult) BetLeg {
+		o := BetSettlementOutcome{Result: r}
+		if r == OutcomeResultWinWithDeadHead {
+			o.DeadHeatFactor = 0.5
+		}
+		return BetLeg{Odds: odds, Outcome: o}
+	}
+	p, ok := AccumulatorPayout(10, []BetLeg{leg(2, OutcomeResultWin), leg(3, OutcomeResultVoid), leg(1.5, OutcomeResultWin)})
+	assert.True(t, ok)
+	assert.InDelta(t, 30, p.Win, 1e-9)
+
+	p, ok = AccumulatorPayout(10, []BetLeg{leg(2, OutcomeResultHalfWin), leg(4, OutcomeResultWinWithDeadHead)})
+	assert.True(t, ok)
+	assert.InDelta(t, 10*1.5*2, p.Total(), 1e-9)
+
+	p, ok = AccumulatorPayout(10, []BetLeg{leg(2, OutcomeResultVoid), leg(4, OutcomeResultHalfLose)})
+	assert.True(t, ok)
+	assert.Equal(t, Payout{Refund: 5}, p)
+
+	p, ok = AccumulatorPayout(10, []BetLeg{leg(2, OutcomeResultUnknown), leg(4, OutcomeResultLose)})
+	assert.True(t, ok)
+	assert.Equal(t, Payout{}, p)
+
+	_, ok = AccumulatorPayout(10, []BetLeg{leg(2, OutcomeResultUnknown), leg(4, OutcomeResultWin)})
+	assert.False(t, ok)
+}
@@ -0,0 +1,418 @@
+package pipe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// OverflowPolicy decides what happens with the incoming message when the
+// consumer queue is full.
+type OverflowPolicy int8
+
+const (
+	// OverflowBlock waits until consumer makes room in the queue. Slow
+	// consumer slows down the whole pipeline.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops queued odds_change messages superseded by the
+	// incoming odds_change (same event and producer, all of its markets present
+	// in the new message). Other messages are never dropped, if there is
+	// nothing to drop it blocks.
+	OverflowDropOldest
+	// OverflowSpill writes messages to the file on disk while the queue is
+	// full. Consumer receives them in the original order.
+	OverflowSpill
+)
+
+// ConsumerConfig configures queue between the pipeline and the consumer.
+type ConsumerConfig struct {
+	// Name of the consumer reported in the slow consumer message.
+	Name string
+	// Buffer is size of the in memory queue.
+	Buffer   int
+	Overflow OverflowPolicy
+	// SpillDir for the OverflowSpill files, os.TempDir if empty.
+	SpillDir string
+	// SlowThreshold after which uof.MessageTypeSlowConsumer message is sent
+	// down the pipeline if the consumer has not received any message while
+	// there are pending ones. Zero disables the watchdog.
+	SlowThreshold time.Duration
+}
+
+// GuardedConsumer is consumer stage with the queue between pipeline and the
+// consumer. Overflow policy protects pipeline (and queue connection) from a
+// single slow consumer.
+func GuardedConsumer(consumer ConsumerStage, cfg ConsumerConfig) InnerStage {
+	return func(in <-chan *uof.Message) (<-chan *uof.Message, <-chan error) {
+		out := make(chan *uof.Message)
+		errc := make(chan error)
+		consumerIn := make(chan *uof.Message)
+		q := newConsumerQueue(cfg)
+		done := make(chan struct{})
+
+		var outWg, errWg sync.WaitGroup
+		outWg.Add(2)
+		errWg.Add(3)
+		go func() { // tee in to out and queue
+			defer errWg.Done()
+			defer outWg.Done()
+			defer close(done)
+			defer q.close()
+			for m := range in {
+				if err := q.push(m); err != nil {
+					errc <- err
+				}
+				out <- m
+			}
+		}()
+		go func() { // watchdog
+			defer outWg.Done()
+			if cfg.SlowThreshold <= 0 {
+				return
+			}
+			t := time.NewTicker(cfg.SlowThreshold / 2)
+			defer t.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-t.C:
+					if s, ok := q.slow(); ok {
+						out <- uof.NewSlowConsumerMessage(s)
+					}
+				}
+			}
+		}()
+		go func() { // queue to consumer
+			defer errWg.Done()
+			defer close(consumerIn)
+			for {
+				m, err := q.pop()
+				if err != nil {
+					errc <- err
+					continue
+				}
+				if m == nil {
+					return
+				}
+				consumerIn <- m
+				q.drained()
+			}
+		}()
+		go func() {
+			defer errWg.Done()
+			if err := consumer(consumerIn); err != nil {
+				errc <- err
+			}
+			go func() { // for unclean exit; drain this chan
+				for range consumerIn {
+				}
+			}()
+		}()
+
+		go func() {
+			outWg.Wait()
+			close(out)
+		}()
+		go func() {
+			errWg.Wait()
+			q.cleanup()
+			close(errc)
+		}()
+		return out, errc
+	}
+}
+
+type consumerQueue struct {
+	cfg       ConsumerConfig
+	mem       []*uof.Message
+	spill     *spillFile
+	closed    bool
+	sending   bool // popped message not yet received by the consumer
+	lastDrain time.Time
+	reported  bool
+	dropped   int
+	spilled   int
+	sync.Mutex
+	cond *sync.Cond
+}
+
+func newConsumerQueue(cfg ConsumerConfig) *consumerQueue {
+	if cfg.Buffer < 1 {
+		cfg.Buffer = 1
+	}
+	q := &consumerQueue{cfg: cfg, lastDrain: time.Now()}
+	q.cond = sync.NewCond(q)
+	return q
+}
+
+func (q *consumerQueue) full() bool {
+	return len(q.mem) >= q.cfg.Buffer
+}
+
+func (q *consumerQueue) pending() int {
+	n := len(q.mem)
+	if q.spill != nil {
+		n += q.spill.pending
+	}
+	return n
+}
+
+func (q *consumerQueue) push(m *uof.Message) error {
+	q.Lock()
+	defer q.Unlock()
+	defer q.cond.Broadcast()
+
+	if q.pending() == 0 && !q.sending {
+		q.lastDrain = time.Now() // consumer was idle, start measuring from now
+	}
+	switch q.cfg.Overflow {
+	case OverflowSpill:
+		if q.full() || (q.spill != nil && q.spill.pending > 0) {
+			if err := q.spillMessage(m); err != nil {
+				// putting it into memory would deliver it before the spilled ones
+				q.dropped++
+				return err
+			}
+			return nil
+		}
+	case OverflowDropOldest:
+		if q.full() && m.Type == uof.MessageTypeOddsChange {
+			q.dropSuperseded(m)
+		}
+	}
+	for q.full() {
+		q.cond.Wait()
+	}
+	q.mem = append(q.mem, m)
+	return nil
+}
+
+func (q *consumerQueue) spillMessage(m *uof.Message) error {
+	if q.spill == nil {
+		s, err := newSpillFile(q.cfg.SpillDir)
+		if err != nil {
+			return err
+		}
+		q.spill = s
+	}
+	if err := q.spill.write(m); err != nil {
+		return err
+	}
+	q.spilled++
+	return nil
+}
+
+func (q *consumerQueue) dropSuperseded(m *uof.Message) {
+	keep := q.mem[:0]
+	for _, o := range q.mem {
+		if supersedes(m, o) {
+			q.dropped++
+			continue
+		}
+		keep = append(keep, o)
+	}
+	for i := len(keep); i < len(q.mem); i++ {
+		q.mem[i] = nil
+	}
+	q.mem = keep
+}
+
+// supersedes reports whether odds change n contains all markets of the
+// older odds change o
+func supersedes(n, o *uof.Message) bool {
+	if o.Type != uof.MessageTypeOddsChange || o.OddsChange == nil || n.OddsChange == nil ||
+		o.EventID != n.EventID || o.Producer != n.Producer || len(o.OddsChange.Markets) == 0 {
+		return false
+	}
+	lines := make(map[[2]int]struct{})
+	for _, mk := range n.OddsChange.Markets {
+		lines[[2]int{mk.ID, mk.LineID}] = struct{}{}
+	}
+	for _, mk := range o.OddsChange.Markets {
+		if _, ok := lines[[2]int{mk.ID, mk.LineID}]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// pop waits for the next message, returns nil when queue is closed and empty.
+// Spill file is read outside of the lock so push is not blocked by the disk.
+func (q *consumerQueue) pop() (*uof.Message, error) {
+	q.Lock()
+	defer q.Unlock()
+	defer q.cond.Broadcast()
+
+	for q.pending() == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.mem) > 0 {
+		m := q.mem[0]
+		q.mem[0] = nil
+		q.mem = q.mem[1:]
+		q.sending = true
+		return m, nil
+	}
+	if q.spill == nil || q.spill.pending == 0 {
+		return nil, nil
+	}
+	s := q.spill
+	if err := s.flush(); err != nil {
+		return nil, q.dropSpill(err)
+	}
+	q.sending = true
+
+	// only this goroutine reads or removes the spill file
+	q.Unlock()
+	m, err := s.read()
+	q.Lock()
+
+	if err != nil {
+		return nil, q.dropSpill(err)
+	}
+	s.pending--
+	s.truncate()
+	return m, nil
+}
+
+// dropSpill discards broken spill file with all pending messages in it
+func (q *consumerQueue) dropSpill(err error) error {
+	lost := q.spill.pending
+	q.spill.remove()
+	q.spill = nil
+	q.dropped += lost
+	q.sending = false
+	return uof.Notice("spill", fmt.Errorf("%d messages lost: %w", lost, err))
+}
+
+func (q *consumerQueue) drained() {
+	q.Lock()
+	defer q.Unlock()
+	q.lastDrain = time.Now()
+	q.reported = false
+	q.sending = false
+}
+
+// slow returns slow consumer report once for each stall
+func (q *consumerQueue) slow() (uof.SlowConsumer, bool) {
+	q.Lock()
+	defer q.Unlock()
+	stalled := time.Since(q.lastDrain)
+	pending := q.pending()
+	if q.sending {
+		pending++
+	}
+	if q.reported || pending == 0 || stalled < q.cfg.SlowThreshold {
+		return uof.SlowConsumer{}, false
+	}
+	q.reported = true
+	return uof.SlowConsumer{
+		Consumer: q.cfg.Name,
+		Pending:  pending,
+		Stalled:  int(stalled / time.Millisecond),
+		Dropped:  q.dropped,
+		Spilled:  q.spilled,
+	}, true
+}
+
+func (q *consumerQueue) close() {
+	q.Lock()
+	defer q.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *consumerQueue) cleanup() {
+	q.Lock()
+	defer q.Unlock()
+	if q.spill != nil {
+		q.spill.remove()
+	}
+}
+
+// spillFile is fifo of messages on disk. Records are written in the same
+// format as in the segment log. File is truncated when all records are read.
+type spillFile struct {
+	w       *os.File
+	wb      *bufio.Writer
+	r       *os.File
+	rb      *bufio.Reader
+	pending int
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	w, err := ioutil.TempFile(dir, "uof-spill-")
+	if err != nil {
+		return nil, uof.Notice("spill open", err)
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		w.Close()
+		os.Remove(w.Name())
+		return nil, uof.Notice("spill open", err)
+	}
+	return &spillFile{w: w, wb: bufio.NewWriter(w), r: r, rb: bufio.NewReader(r)}, nil
+}
+
+func (s *spillFile) write(m *uof.Message) error {
+	buf := m.Marshal()
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(buf)))
+	if _, err := s.wb.Write(hdr[:]); err != nil {
+		return uof.Notice("spill write", err)
+	}
+	if _, err := s.wb.Write(buf); err != nil {
+		return uof.Notice("spill write", err)
+	}
+	s.pending++
+	return nil
+}
+
+func (s *spillFile) flush() error {
+	if err := s.wb.Flush(); err != nil {
+		return uof.Notice("spill write", err)
+	}
+	return nil
+}
+
+// read next record, file must be flushed
+func (s *spillFile) read() (*uof.Message, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(s.rb, hdr[:]); err != nil {
+		return nil, uof.Notice("spill read", err)
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+	if _, err := io.ReadFull(s.rb, buf); err != nil {
+		return nil, uof.Notice("spill read", err)
+	}
+	m := &uof.Message{}
+	if err := m.Unmarshal(buf); err != nil {
+		return nil, uof.Notice("spill unmarshal", err)
+	}
+	return m, nil
+}
+
+// truncate file when everything is read
+func (s *spillFile) truncate() {
+	if s.pending > 0 {
+		return
+	}
+	s.w.Truncate(0)
+	s.w.Seek(0, io.SeekStart)
+	s.r.Seek(0, io.SeekStart)
+	s.rb.Reset(s.r)
+}
+
+func (s *spillFile) remove() {
+	s.w.Close()
+	s.r.Close()
+	os.Remove(s.w.Name())
+}
@@ -0,0 +1,141 @@
+package pipe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func sequenceMsg(t *testing.T, i int) *uof.Message {
+	buf := []byte(fmt.Sprintf(`<fixture_change event_id="sr:match:1234" product="3" start_time="%d"/>`, i))
+	m, err := uof.NewQueueMessage("hi.pre.-.fixture_change.1.sr:match.1234.-", buf)
+	assert.NoError(t, err)
+	return m
+}
+
+func TestGuardedConsumerSpill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	release := make(chan struct{})
+	var received []int
+	stage := GuardedConsumer(func(in <-chan *uof.Message) error {
+		<-release
+		for m := range in {
+			received = append(received, *m.FixtureChange.StartTime)
+		}
+		return nil
+	}, ConsumerConfig{Buffer: 2, Overflow: OverflowSpill, SpillDir: dir})
+
+	in := make(chan *uof.Message)
+	out, errc := stage(in)
+	go func() {
+		for i := 1; i <= 20; i++ {
+			in <- sequenceMsg(t, i)
+		}
+		close(in)
+	}()
+	cnt := 0
+	for range out { // pipeline is not blocked by the consumer
+		cnt++
+	}
+	assert.Equal(t, 20, cnt)
+	close(release)
+	for err := range errc {
+		assert.NoError(t, err)
+	}
+	assert.Len(t, received, 20)
+	for i, ts := range received {
+		assert.Equal(t, i+1, ts)
+	}
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestGuardedConsumerSpillErrors(t *testing.T) {
+	// message which can't be spilled is not put in memory before the spilled ones
+	q := newConsumerQueue(ConsumerConfig{Buffer: 1, Overflow: OverflowSpill, SpillDir: "/non/existing/dir"})
+	assert.NoError(t, q.push(sequenceMsg(t, 1)))
+	assert.Error(t, q.push(sequenceMsg(t, 2)))
+	assert.Equal(t, 1, q.pending())
+	assert.Equal(t, 1, q.dropped)
+
+	dir, err := ioutil.TempDir("", "spill")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// unreadable spill file is dropped
+	q = newConsumerQueue(ConsumerConfig{Buffer: 1, Overflow: OverflowSpill, SpillDir: dir})
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, q.push(sequenceMsg(t, i)))
+	}
+	assert.Equal(t, 2, q.spill.pending)
+	m, err := q.pop()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *m.FixtureChange.StartTime)
+	q.drained()
+
+	assert.NoError(t, q.spill.flush())
+	assert.NoError(t, q.spill.w.Truncate(0))
+	m, err = q.pop()
+	assert.Nil(t, m)
+	assert.Error(t, err)
+	assert.Nil(t, q.spill)
+	assert.Equal(t, 2, q.dropped)
+	assert.False(t, q.sending)
+
+	assert.NoError(t, q.push(sequenceMsg(t, 4)))
+	m, err = q.pop()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *m.FixtureChange.StartTime)
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestGuardedConsumerDropOldest(t *testing.T) {
+	q := newConsumerQueue(ConsumerConfig{Buffer: 2, Overflow: OverflowDropOldest})
+	assert.NoError(t, q.push(oddsChangeMessage(t)))
+	assert.NoError(t, q.push(fixtureChangeMsg(t)))
+	assert.NoError(t, q.push(oddsChangeMessage(t)))
+	assert.Equal(t, 2, q.pending())
+	assert.Equal(t, 1, q.dropped)
+	assert.Equal(t, uof.MessageTypeFixtureChange, q.mem[0].Type)
+	assert.Equal(t, uof.MessageTypeOddsChange, q.mem[1].Type)
+}
+
+func TestGuardedConsumerSlow(t *testing.T) {
+	release := make(chan struct{})
+	stage := GuardedConsumer(func(in <-chan *uof.Message) error {
+		<-release
+		for range in {
+		}
+		return nil
+	}, ConsumerConfig{Name: "slow", Buffer: 10, SlowThreshold: 20 * time.Millisecond})
+
+	in := make(chan *uof.Message)
+	out, errc := stage(in)
+	in <- sequenceMsg(t, 1)
+	assert.Equal(t, uof.MessageTypeFixtureChange, (<-out).Type)
+
+	m := <-out
+	assert.Equal(t, uof.MessageTypeSlowConsumer, m.Type)
+	assert.Equal(t, "slow", m.SlowConsumer.Consumer)
+	assert.Equal(t, 1, m.SlowConsumer.Pending)
+	assert.True(t, m.SlowConsumer.Stalled >= 20)
+
+	close(release)
+	close(in)
+	for range out {
+	}
+	for err := range errc {
+		assert.NoError(t, err)
+	}
+}
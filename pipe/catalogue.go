@@ -0,0 +1,64 @@
+package pipe
+
+import (
+	"sync"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+type catalogueAPI interface {
+	Sports(lang uof.Lang) ([]uof.Sport, error)
+	Tournaments(lang uof.Lang) ([]uof.CatalogueTournament, error)
+}
+
+type catalogue struct {
+	api       catalogueAPI
+	languages []uof.Lang
+	errc      chan<- error
+	out       chan<- *uof.Message
+	subProcs  *sync.WaitGroup
+}
+
+// Catalogue stage on start loads sports and tournaments for each language
+// and sends catalogue (sport, category, tournament tree) message. All other
+// messages are passing through.
+func Catalogue(api catalogueAPI, languages []uof.Lang) InnerStage {
+	c := &catalogue{
+		api:       api,
+		languages: languages,
+		subProcs:  &sync.WaitGroup{},
+	}
+	return StageWithSubProcessesSync(c.loop)
+}
+
+func (c *catalogue) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) *sync.WaitGroup {
+	c.errc, c.out = errc, out
+
+	requestedAt := uof.CurrentTimestamp()
+	c.subProcs.Add(len(c.languages))
+	for _, lang := range c.languages {
+		go func(lang uof.Lang) {
+			defer c.subProcs.Done()
+			if err := c.load(lang, requestedAt); err != nil {
+				c.errc <- err
+			}
+		}(lang)
+	}
+	for m := range in {
+		out <- m
+	}
+	return c.subProcs
+}
+
+func (c *catalogue) load(lang uof.Lang, requestedAt int) error {
+	sports, err := c.api.Sports(lang)
+	if err != nil {
+		return err
+	}
+	tournaments, err := c.api.Tournaments(lang)
+	if err != nil {
+		return err
+	}
+	c.out <- uof.NewCatalogueMessage(lang, uof.NewCatalogue(sports, tournaments), requestedAt)
+	return nil
+}
@@ -0,0 +1,47 @@
+package pipe
+
+import (
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+type catalogueAPIMock struct{}
+
+func (m catalogueAPIMock) Sports(lang uof.Lang) ([]uof.Sport, error) {
+	return []uof.Sport{{ID: 1, URN: "sr:sport:1", Name: "Soccer"}}, nil
+}
+
+func (m catalogueAPIMock) Tournaments(lang uof.Lang) ([]uof.CatalogueTournament, error) {
+	return []uof.CatalogueTournament{{
+		ID:       17,
+		URN:      "sr:tournament:17",
+		Sport:    uof.Sport{ID: 1, URN: "sr:sport:1"},
+		Category: uof.Category{ID: 1, URN: "sr:category:1"},
+	}}, nil
+}
+
+func TestCataloguePipe(t *testing.T) {
+	c := Catalogue(catalogueAPIMock{}, []uof.Lang{uof.LangEN, uof.LangDE})
+	in := make(chan *uof.Message)
+	out, _ := c(in)
+
+	m := uof.NewConnnectionMessage(uof.ConnectionStatusUp)
+	go func() {
+		in <- m
+		close(in)
+	}()
+
+	langs := make(map[uof.Lang]bool)
+	cnt := 0
+	for om := range out {
+		cnt++
+		if om.Is(uof.MessageTypeCatalogue) {
+			langs[om.Lang] = true
+			assert.Len(t, om.Catalogue.Sports[0].Categories, 1)
+		}
+	}
+	assert.Equal(t, 3, cnt)
+	assert.Len(t, langs, 2)
+}
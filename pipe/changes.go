@@ -0,0 +1,141 @@
+package pipe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+type changesAPI interface {
+	FixtureChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error)
+	ResultChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error)
+	Fixture(lang uof.Lang, eventURN uof.URN) (*uof.Fixture, error)
+	Summary(lang uof.Lang, eventURN uof.URN) (*uof.Summary, error)
+}
+
+// changesSince is the start of the last successful poll of each endpoint
+type changesSince struct {
+	fixtures time.Time
+	results  time.Time
+}
+
+type changes struct {
+	api       changesAPI
+	languages []uof.Lang
+	interval  time.Duration
+	since     map[uof.Lang]*changesSince
+	errc      chan<- error
+	out       chan<- *uof.Message
+	rateLimit chan struct{}
+	subProcs  *sync.WaitGroup
+}
+
+// ChangesPoll stage polls fixture and result changes in the interval. Fixture
+// is fetched for each event with changed fixture and summary for each event
+// with changed result since the previous poll. It is a safety net for missed
+// fixture_change messages. Failed poll is repeated from the same time in the
+// next interval.
+func ChangesPoll(api changesAPI, languages []uof.Lang, interval time.Duration) InnerStage {
+	c := &changes{
+		api:       api,
+		languages: languages,
+		interval:  interval,
+		since:     make(map[uof.Lang]*changesSince),
+		subProcs:  &sync.WaitGroup{},
+		rateLimit: make(chan struct{}, ConcurentAPICallsLimit),
+	}
+	now := time.Now()
+	for _, lang := range languages {
+		c.since[lang] = &changesSince{fixtures: now, results: now}
+	}
+	return StageWithSubProcessesSync(c.loop)
+}
+
+func (c *changes) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) *sync.WaitGroup {
+	c.errc, c.out = errc, out
+
+	done := make(chan struct{})
+	c.subProcs.Add(1)
+	go func() {
+		defer c.subProcs.Done()
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.poll()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for m := range in {
+		out <- m
+	}
+	close(done)
+	return c.subProcs
+}
+
+func (c *changes) poll() {
+	now := time.Now()
+	requestedAt := uof.CurrentTimestamp()
+	var wg sync.WaitGroup
+	wg.Add(len(c.languages))
+	for _, lang := range c.languages {
+		// each language goroutine changes only its own since
+		go func(lang uof.Lang, since *changesSince) {
+			defer wg.Done()
+			if fcs, err := c.api.FixtureChanges(lang, since.fixtures); err != nil {
+				c.errc <- err
+			} else {
+				since.fixtures = now
+				for _, ec := range fcs {
+					c.fixture(&wg, lang, ec.EventURN, requestedAt)
+				}
+			}
+			if rcs, err := c.api.ResultChanges(lang, since.results); err != nil {
+				c.errc <- err
+			} else {
+				since.results = now
+				for _, ec := range rcs {
+					c.summary(&wg, lang, ec.EventURN, requestedAt)
+				}
+			}
+		}(lang, c.since[lang])
+	}
+	wg.Wait()
+}
+
+func (c *changes) fixture(wg *sync.WaitGroup, lang uof.Lang, eventURN uof.URN, requestedAt int) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.rateLimit <- struct{}{}
+		defer func() { <-c.rateLimit }()
+
+		x, err := c.api.Fixture(lang, eventURN)
+		if err != nil {
+			c.errc <- err
+			return
+		}
+		c.out <- uof.NewFixtureMessage(lang, *x, requestedAt)
+	}()
+}
+
+func (c *changes) summary(wg *sync.WaitGroup, lang uof.Lang, eventURN uof.URN, requestedAt int) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.rateLimit <- struct{}{}
+		defer func() { <-c.rateLimit }()
+
+		x, err := c.api.Summary(lang, eventURN)
+		if err != nil {
+			c.errc <- err
+			return
+		}
+		c.out <- uof.NewSummaryMessage(lang, *x, requestedAt)
+	}()
+}
@@ -0,0 +1,122 @@
+package pipe
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+type changesAPIMock struct {
+	polls []time.Time
+	sync.Mutex
+}
+
+func (m *changesAPIMock) FixtureChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.polls = append(m.polls, after)
+	if len(m.polls) > 1 {
+		return nil, nil
+	}
+	return []uof.EventChange{{EventID: 1, EventURN: "sr:match:1"}, {EventID: 2, EventURN: "sr:match:2"}}, nil
+}
+
+func (m *changesAPIMock) ResultChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	m.Lock()
+	defer m.Unlock()
+	if len(m.polls) > 1 {
+		return nil, nil
+	}
+	return []uof.EventChange{{EventID: 3, EventURN: "sr:match:3"}}, nil
+}
+
+func (m *changesAPIMock) Fixture(lang uof.Lang, eventURN uof.URN) (*uof.Fixture, error) {
+	return &uof.Fixture{ID: eventURN.EventID(), URN: eventURN}, nil
+}
+
+func (m *changesAPIMock) Summary(lang uof.Lang, eventURN uof.URN) (*uof.Summary, error) {
+	return &uof.Summary{SportEvent: uof.Fixture{ID: eventURN.EventID(), URN: eventURN}}, nil
+}
+
+func TestChangesPoll(t *testing.T) {
+	a := &changesAPIMock{}
+	c := ChangesPoll(a, []uof.Lang{uof.LangEN}, 10*time.Millisecond)
+	in := make(chan *uof.Message)
+	out, _ := c(in)
+
+	types := make(map[uof.MessageType]int)
+	for i := 0; i < 3; i++ {
+		m := <-out
+		types[m.Type]++
+	}
+	assert.Equal(t, 2, types[uof.MessageTypeFixture])
+	assert.Equal(t, 1, types[uof.MessageTypeSummary])
+
+	time.Sleep(30 * time.Millisecond)
+	close(in)
+	for range out {
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	assert.True(t, len(a.polls) > 1)
+	// each poll continues from the start of the previous one
+	assert.True(t, a.polls[1].After(a.polls[0]))
+}
+
+// failingChangesAPIMock fails first fixture changes call
+type failingChangesAPIMock struct {
+	changesAPIMock
+	fixtures []time.Time
+	results  []time.Time
+}
+
+func (m *failingChangesAPIMock) FixtureChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.fixtures = append(m.fixtures, after)
+	if len(m.fixtures) == 1 {
+		return nil, uof.Notice("fixture changes", errors.New("timeout"))
+	}
+	return nil, nil
+}
+
+func (m *failingChangesAPIMock) ResultChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.results = append(m.results, after)
+	return nil, nil
+}
+
+func TestChangesPollError(t *testing.T) {
+	a := &failingChangesAPIMock{}
+	c := ChangesPoll(a, []uof.Lang{uof.LangEN}, 10*time.Millisecond)
+	in := make(chan *uof.Message)
+	_, errc := c(in)
+	<-errc
+
+	for {
+		a.Lock()
+		n := len(a.fixtures)
+		a.Unlock()
+		if n > 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(in)
+	for range errc {
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	// failed poll is repeated from the same time
+	assert.Equal(t, a.fixtures[0], a.fixtures[1])
+	assert.True(t, a.fixtures[2].After(a.fixtures[1]))
+	// results are independent of fixtures
+	assert.True(t, a.results[1].After(a.results[0]))
+}
@@ -0,0 +1,172 @@
+package pipe
+
+import (
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+type coalesced struct {
+	key      eventKey
+	m        *uof.Message // latest received odds change
+	merged   bool         // m is a copy with merged markets
+	deadline time.Time
+}
+
+type coalesce struct {
+	window  time.Duration
+	pending map[eventKey]*coalesced
+	order   []eventKey // by deadline
+}
+
+// Coalesce merges consecutive odds change messages of the event received
+// within window into one message. Merged message holds the latest state of
+// each market line and other attributes of the last odds change.
+//
+// Only odds changes are delayed. Any other message of the event (bet stop,
+// settlement, fixture change...) first flushes pending odds change and is
+// passed without delay. Odds change which changes event status or has market
+// which is not active is also passed immediately.
+func Coalesce(window time.Duration) InnerStage {
+	c := &coalesce{
+		window:  window,
+		pending: make(map[eventKey]*coalesced),
+	}
+	return Stage(c.loop)
+}
+
+func (c *coalesce) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+	timer := time.NewTimer(c.window)
+	defer timer.Stop()
+	for {
+		select {
+		case m, ok := <-in:
+			if !ok {
+				c.flushAll(out)
+				return
+			}
+			c.handle(m, out)
+		case <-timer.C:
+			c.flushExpired(out)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(c.next())
+	}
+}
+
+// next duration until the first deadline
+func (c *coalesce) next() time.Duration {
+	if len(c.order) == 0 {
+		return c.window
+	}
+	d := time.Until(c.pending[c.order[0]].deadline)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func (c *coalesce) handle(m *uof.Message, out chan<- *uof.Message) {
+	if m.Type.Kind() != uof.MessageKindEvent {
+		out <- m
+		return
+	}
+	key := messageEventKey(m)
+	if m.Type != uof.MessageTypeOddsChange || m.OddsChange == nil {
+		c.flush(key, out)
+		out <- m
+		return
+	}
+	p, ok := c.pending[key]
+	if !ok {
+		if urgent(nil, m) {
+			out <- m
+			return
+		}
+		c.pending[key] = &coalesced{key: key, m: m, deadline: time.Now().Add(c.window)}
+		c.order = append(c.order, key)
+		return
+	}
+	flush := urgent(p, m)
+	p.merge(m)
+	if flush {
+		c.flush(key, out)
+	}
+}
+
+// urgent odds change changes status of the event or some market
+func urgent(p *coalesced, m *uof.Message) bool {
+	for _, mk := range m.OddsChange.Markets {
+		if mk.Status != uof.MarketStatusActive {
+			return true
+		}
+	}
+	if p == nil {
+		return false
+	}
+	prev, cur := p.m.OddsChange.EventStatus, m.OddsChange.EventStatus
+	return prev != nil && cur != nil && prev.Status != cur.Status
+}
+
+// merge odds change m into the pending one
+func (p *coalesced) merge(m *uof.Message) {
+	markets := p.m.OddsChange.Markets
+	if !p.merged {
+		markets = append([]uof.Market(nil), markets...)
+	}
+	for _, mk := range m.OddsChange.Markets {
+		replaced := false
+		for i, e := range markets {
+			if e.ID == mk.ID && e.LineID == mk.LineID {
+				markets[i] = mk
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			markets = append(markets, mk)
+		}
+	}
+	oc := *m.OddsChange
+	oc.Markets = markets
+	// copy of the message without Raw, raw xml no longer describes the body
+	p.m = &uof.Message{Header: m.Header, Body: uof.Body{OddsChange: &oc}}
+	p.merged = true
+}
+
+func (c *coalesce) flush(key eventKey, out chan<- *uof.Message) {
+	p, ok := c.pending[key]
+	if !ok {
+		return
+	}
+	delete(c.pending, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	out <- p.m
+}
+
+func (c *coalesce) flushExpired(out chan<- *uof.Message) {
+	now := time.Now()
+	for len(c.order) > 0 {
+		p := c.pending[c.order[0]]
+		if p.deadline.After(now) {
+			return
+		}
+		c.flush(p.key, out)
+	}
+}
+
+func (c *coalesce) flushAll(out chan<- *uof.Message) {
+	for len(c.order) > 0 {
+		c.flush(c.order[0], out)
+	}
+}
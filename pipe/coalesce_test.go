@@ -0,0 +1,73 @@
+package pipe
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func coalesceOddsChange(t *testing.T, eventID int, status int, markets ...string) *uof.Message {
+	buf := fmt.Sprintf(`<odds_change event_id="sr:match:%d" timestamp="1" product="1"><sport_event_status status="%d"/><odds>`, eventID, status)
+	for _, mk := range markets {
+		buf += mk
+	}
+	buf += `</odds></odds_change>`
+	m, err := uof.NewQueueMessage(fmt.Sprintf("hi.-.live.odds_change.1.sr:match.%d.-", eventID), []byte(buf))
+	assert.NoError(t, err)
+	return m
+}
+
+func market(id int, odds string, status int) string {
+	return fmt.Sprintf(`<market id="%d" status="%d"><outcome id="1" odds="%s" active="1"/></market>`, id, status, odds)
+}
+
+func TestCoalesce(t *testing.T) {
+	in := make(chan *uof.Message)
+	out, _ := Coalesce(50 * time.Millisecond)(in)
+
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.1", 1), market(2, "2.1", 1))
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.2", 1))
+	in <- coalesceOddsChange(t, 2, 1, market(1, "3.1", 1))
+	in <- coalesceOddsChange(t, 1, 1, market(3, "4.1", 1))
+
+	// merged when window expires
+	m := <-out
+	assert.Equal(t, 1, m.EventID)
+	assert.Nil(t, m.Raw)
+	assert.Len(t, m.OddsChange.Markets, 3)
+	assert.Equal(t, 1.2, *m.OddsChange.Markets[0].Outcomes[0].Odds)
+	assert.Equal(t, 2.1, *m.OddsChange.Markets[1].Outcomes[0].Odds)
+	assert.Equal(t, 4.1, *m.OddsChange.Markets[2].Outcomes[0].Odds)
+	m = <-out
+	assert.Equal(t, 2, m.EventID)
+	assert.NotNil(t, m.Raw) // single message is passed as is
+
+	// bet stop flushes pending odds change and is not delayed
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.3", 1))
+	bs, err := uof.NewQueueMessage("hi.-.live.bet_stop.1.sr:match.1.-", []byte(`<bet_stop event_id="sr:match:1" product="1" timestamp="2" groups="all"/>`))
+	assert.NoError(t, err)
+	start := time.Now()
+	in <- bs
+	assert.Equal(t, uof.MessageTypeOddsChange, (<-out).Type)
+	assert.Equal(t, uof.MessageTypeBetStop, (<-out).Type)
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	// suspended market and event status change are not delayed
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.4", -1))
+	assert.Equal(t, uof.MarketStatusSuspended, (<-out).OddsChange.Markets[0].Status)
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.5", 1))
+	in <- coalesceOddsChange(t, 1, 3, market(2, "2.5", 1))
+	m = <-out
+	assert.Len(t, m.OddsChange.Markets, 2)
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	// pending are flushed on close
+	in <- coalesceOddsChange(t, 1, 3, market(1, "1.6", 1))
+	close(in)
+	assert.Equal(t, 1.6, *(<-out).OddsChange.Markets[0].Outcomes[0].Odds)
+	_, ok := <-out
+	assert.False(t, ok)
+}
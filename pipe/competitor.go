@@ -8,7 +8,7 @@ import (
 )
 
 type competitorAPI interface {
-	Competitor(lang uof.Lang, competitorID int) (*uof.CompetitorPlayer, error)
+	Competitor(lang uof.Lang, competitorID int) (*uof.CompetitorProfile, error)
 }
 
 type competitor struct {
@@ -21,6 +21,9 @@ type competitor struct {
 	subProcs  *sync.WaitGroup
 }
 
+// Competitor stage fetches competitor profiles for competitors from the
+// outcomes of odds changes and from competitors of fixtures. Profile is
+// fetched once an hour for each competitor and language.
 func Competitor(api competitorAPI, languages []uof.Lang) InnerStage {
 	p := &competitor{
 		api:       api,
@@ -42,13 +45,23 @@ func (p *competitor) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc
 				p.get(competitorID, m.ReceivedAt)
 			})
 		}
+		if m.Is(uof.MessageTypeFixture) {
+			for _, c := range m.Fixture.Competitors {
+				if c.URN.IsCompetitor() {
+					p.get(c.ID, m.ReceivedAt)
+				}
+			}
+		}
 	}
 	return p.subProcs
 }
 
 func (p *competitor) get(competitorID, requestedAt int) {
-	p.subProcs.Add(len(p.languages))
 	for _, lang := range p.languages {
+		if p.em.fresh(uof.UIDWithLang(competitorID, lang)) {
+			continue
+		}
+		p.subProcs.Add(1)
 		go func(lang uof.Lang) {
 			defer p.subProcs.Done()
 			p.rateLimit <- struct{}{}
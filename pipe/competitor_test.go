@@ -0,0 +1,72 @@
+package pipe
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+type competitorAPIMock struct {
+	requests map[int]struct{}
+	calls    int
+	sync.Mutex
+}
+
+func (m *competitorAPIMock) Competitor(lang uof.Lang, competitorID int) (*uof.CompetitorProfile, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.requests[uof.UIDWithLang(competitorID, lang)] = struct{}{}
+	m.calls++
+	return &uof.CompetitorProfile{}, nil
+}
+
+func TestCompetitorPipe(t *testing.T) {
+	a := &competitorAPIMock{requests: make(map[int]struct{})}
+	p := Competitor(a, []uof.Lang{uof.LangEN, uof.LangDE})
+
+	in := make(chan *uof.Message)
+	out, _ := p(in)
+
+	f := uof.Fixture{
+		URN: "sr:match:1",
+		Competitors: []uof.Competitor{
+			{ID: 2953, URN: "sr:competitor:2953"},
+			{ID: 33, URN: "sr:competitor:33"},
+			{ID: 7, URN: "vf:competitor:7"}, // not fetched
+		},
+	}
+	m := uof.NewFixtureMessage(uof.LangEN, f, 0)
+	in <- m
+	assert.Equal(t, m, <-out)
+	// summary has fixture but does not trigger fetch
+	s := uof.NewSummaryMessage(uof.LangEN, uof.Summary{SportEvent: uof.Fixture{
+		Competitors: []uof.Competitor{{ID: 44, URN: "sr:competitor:44"}},
+	}}, 0)
+	in <- s
+	cnt := 0
+	for om := <-out; !om.Is(uof.MessageTypeSummary); om = <-out {
+		cnt++
+	}
+
+	close(in)
+	for om := range out {
+		assert.True(t, om.Is(uof.MessageTypeCompetitor))
+		cnt++
+	}
+	assert.Equal(t, 4, cnt)
+	assert.Len(t, a.requests, 4)
+	assert.Equal(t, 4, a.calls)
+
+	// seen competitors are not fetched again
+	in = make(chan *uof.Message, 1)
+	out, _ = p(in)
+	in <- m
+	close(in)
+	for range out {
+	}
+	assert.Equal(t, 4, a.calls)
+	_, ok := a.requests[uof.UIDWithLang(2953, uof.LangDE)]
+	assert.True(t, ok)
+}
@@ -0,0 +1,156 @@
+package pipe
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// DedupConfig configures deduplication of the event messages.
+type DedupConfig struct {
+	// Window in which the same message is considered duplicate.
+	Window time.Duration
+	// MaxSize of the fingerprint set, oldest fingerprints are removed first.
+	// Default is DefaultDedupMaxSize.
+	MaxSize int
+	// Flag duplicates (Header.Duplicate) instead of dropping them.
+	Flag bool
+	// KeepRequestID includes request id in the fingerprint. By default
+	// recovery message is duplicate of the same live message.
+	KeepRequestID bool
+}
+
+// DefaultDedupMaxSize is the default limit of the fingerprint set.
+const DefaultDedupMaxSize = 1000000
+
+// DedupStats counters since the stage start.
+type DedupStats struct {
+	Messages   int // event messages checked
+	Duplicates int
+	Size       int // current size of the fingerprint set
+}
+
+// Deduplicator finds event messages received more than once; during recovery,
+// reconnect or from multiple nodes.
+//
+// Message fingerprint is made of producer, type, event, timestamp and the hash
+// of raw message content without request id.
+type Deduplicator struct {
+	cfg   DedupConfig
+	em    *expireMap
+	stats DedupStats
+	sync.Mutex
+}
+
+// NewDeduplicator creates deduplicator, use its Stage in the pipeline.
+func NewDeduplicator(cfg DedupConfig) *Deduplicator {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Hour
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultDedupMaxSize
+	}
+	return &Deduplicator{
+		cfg: cfg,
+		em:  newBoundedExpireMap(cfg.Window, cfg.MaxSize),
+	}
+}
+
+// Dedup stage drops (or flags) duplicate event messages.
+func Dedup(cfg DedupConfig) InnerStage {
+	return NewDeduplicator(cfg).Stage()
+}
+
+// Stage of the pipeline.
+func (d *Deduplicator) Stage() InnerStage {
+	return Stage(d.loop)
+}
+
+// Stats returns current counters.
+func (d *Deduplicator) Stats() DedupStats {
+	d.Lock()
+	defer d.Unlock()
+	s := d.stats
+	s.Size = d.em.size()
+	return s
+}
+
+func (d *Deduplicator) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+	for m := range in {
+		if m.Type.Kind() == uof.MessageKindEvent {
+			if d.duplicate(m) {
+				if !d.cfg.Flag {
+					continue
+				}
+				m.Duplicate = true
+			}
+		}
+		out <- m
+	}
+}
+
+func (d *Deduplicator) duplicate(m *uof.Message) bool {
+	fp := d.fingerprint(m)
+	dup := d.em.fresh(fp)
+	if !dup {
+		d.em.insert(fp)
+	}
+	d.Lock()
+	defer d.Unlock()
+	d.stats.Messages++
+	if dup {
+		d.stats.Duplicates++
+	}
+	return dup
+}
+
+var requestIDAttr = regexp.MustCompile(`\s+request_id="[^"]*"`)
+
+func (d *Deduplicator) fingerprint(m *uof.Message) int {
+	raw := m.Raw
+	if raw == nil {
+		// event message made by the sdk, without the queue content
+		raw, _ = json.Marshal(m.Body)
+	}
+	requestID := requestID(m)
+	if !d.cfg.KeepRequestID {
+		raw = requestIDAttr.ReplaceAll(raw, nil)
+		requestID = 0
+	}
+	h := fnv.New64a()
+	for _, v := range []int{int(m.Producer), int(m.Type), m.EventID, m.Timestamp, requestID} {
+		h.Write([]byte(strconv.Itoa(v)))
+		h.Write([]byte{0})
+	}
+	h.Write(raw)
+	return int(h.Sum64())
+}
+
+func requestID(m *uof.Message) int {
+	var id *int
+	switch {
+	case m.OddsChange != nil:
+		id = m.OddsChange.RequestID
+	case m.BetSettlement != nil:
+		id = m.BetSettlement.RequestID
+	case m.BetCancel != nil:
+		id = m.BetCancel.RequestID
+	case m.BetStop != nil:
+		id = m.BetStop.RequestID
+	case m.FixtureChange != nil:
+		id = m.FixtureChange.RequestID
+	case m.RollbackBetSettlement != nil:
+		id = m.RollbackBetSettlement.RequestID
+	case m.RollbackBetCancel != nil:
+		id = m.RollbackBetCancel.RequestID
+	}
+	if id == nil {
+		return 0
+	}
+	return *id
+}
@@ -0,0 +1,59 @@
+package pipe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupStage(t *testing.T) {
+	live := `<bet_settlement event_id="sr:match:1" product="1" timestamp="10" certainty="2"><outcomes></outcomes></bet_settlement>`
+	recovery := `<bet_settlement event_id="sr:match:1" product="1" timestamp="10" certainty="2" request_id="7"><outcomes></outcomes></bet_settlement>`
+	msg := func(buf string) *uof.Message {
+		m, err := uof.NewQueueMessage("lo.-.live.bet_settlement.1.sr:match.1.-", []byte(buf))
+		assert.NoError(t, err)
+		return m
+	}
+	run := func(cfg DedupConfig, ms ...*uof.Message) ([]*uof.Message, DedupStats) {
+		d := NewDeduplicator(cfg)
+		in := make(chan *uof.Message, len(ms))
+		for _, m := range ms {
+			in <- m
+		}
+		close(in)
+		out, _ := d.Stage()(in)
+		var res []*uof.Message
+		for m := range out {
+			res = append(res, m)
+		}
+		return res, d.Stats()
+	}
+
+	res, stats := run(DedupConfig{Window: time.Minute, KeepRequestID: true},
+		msg(live), msg(live), uof.NewConnnectionMessage(uof.ConnectionStatusUp), msg(recovery))
+	assert.Len(t, res, 3)
+	assert.Equal(t, DedupStats{Messages: 3, Duplicates: 1, Size: 2}, stats)
+
+	res, stats = run(DedupConfig{Window: time.Minute, Flag: true},
+		msg(live), msg(recovery))
+	assert.Len(t, res, 2)
+	assert.False(t, res[0].Duplicate)
+	assert.True(t, res[1].Duplicate)
+	assert.Equal(t, 1, stats.Duplicates)
+
+	d := NewDeduplicator(DedupConfig{})
+	assert.Equal(t, DefaultDedupMaxSize, d.cfg.MaxSize)
+}
+
+func TestDedupSettlementChange(t *testing.T) {
+	d := NewDeduplicator(DedupConfig{Window: time.Minute})
+	sc := func(marketID int) *uof.Message {
+		return uof.NewSettlementChangeMessage(uof.SettlementChange{EventID: 1, EventURN: "sr:match:1", Producer: uof.ProducerLiveOdds, Timestamp: 10, MarketID: marketID})
+	}
+	assert.False(t, d.duplicate(sc(1)))
+	// different market of the same bet settlement
+	assert.False(t, d.duplicate(sc(2)))
+	assert.True(t, d.duplicate(sc(1)))
+}
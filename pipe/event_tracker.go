@@ -0,0 +1,145 @@
+package pipe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// closedEventRetention is how long closed or cancelled event is remembered,
+// so that later status change of it is reported as invalid transition
+const closedEventRetention = 6 * time.Hour
+
+// idleEventRetention is how long event without status changes is remembered
+const idleEventRetention = 48 * time.Hour
+
+type trackedEvent struct {
+	state  uof.EventState
+	seen   time.Time // of the last status message
+	closed time.Time // when the event was closed or cancelled
+}
+
+// EventTracker maintains lifecycle state of each event and producer from the
+// sport event status in odds changes and cancellations in fixture changes.
+//
+// First seen state of the event is recorded without transition (except
+// cancellation). Closed and cancelled events are kept for some time and then
+// removed from the tracker, as are events without status messages for two
+// days.
+type EventTracker struct {
+	events    map[eventKey]trackedEvent
+	cleanedAt time.Time
+	sync.Mutex
+}
+
+// NewEventTracker creates empty tracker.
+func NewEventTracker() *EventTracker {
+	return &EventTracker{
+		events: make(map[eventKey]trackedEvent),
+	}
+}
+
+// Stage of the pipeline which updates tracker and sends event transition
+// message after each message which changes event status or match status.
+func (t *EventTracker) Stage() InnerStage {
+	return Stage(func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+		for m := range in {
+			tr, ok := t.Apply(m)
+			out <- m
+			if ok {
+				out <- uof.NewEventTransitionMessage(tr)
+			}
+		}
+	})
+}
+
+// State returns the last known state of the event from the producer.
+func (t *EventTracker) State(eventID int, producer uof.Producer) (uof.EventState, bool) {
+	t.Lock()
+	defer t.Unlock()
+	e, ok := t.events[eventKey{eventID: eventID, producer: producer}]
+	return e.state, ok
+}
+
+// Apply message to the tracker. Returns transition if event status or match
+// status is changed.
+func (t *EventTracker) Apply(m *uof.Message) (uof.EventTransition, bool) {
+	cur, ok := eventState(m)
+	if !ok {
+		return uof.EventTransition{}, false
+	}
+	key := eventKey{eventID: cur.EventID, producer: cur.Producer}
+	now := time.Now()
+
+	t.Lock()
+	defer t.Unlock()
+	t.cleanup(now)
+	e, found := t.events[key]
+	prev := e.state
+	if found {
+		if cur.MatchStatus == nil {
+			cur.MatchStatus = prev.MatchStatus
+		}
+		if cur.HomeScore == nil {
+			cur.HomeScore, cur.AwayScore = prev.HomeScore, prev.AwayScore
+		}
+		if cur.Clock == nil {
+			cur.Clock = prev.Clock
+		}
+	}
+	if !found && cur.Status == uof.EventStatusCancelled {
+		// cancellation of the event not seen before is still reported
+		prev, found = uof.EventState{Status: uof.EventStatusNotStarted}, true
+	}
+	switch cur.Status {
+	case uof.EventStatusClosed, uof.EventStatusCancelled:
+		if e.closed.IsZero() {
+			e.closed = now
+		}
+	default:
+		e.closed = time.Time{}
+	}
+	e.state, e.seen = cur, now
+	t.events[key] = e
+	if !found || (prev.Status == cur.Status && equalInt(prev.MatchStatus, cur.MatchStatus)) {
+		return uof.EventTransition{}, false
+	}
+	return uof.NewEventTransition(prev, cur), true
+}
+
+// cleanup removes events closed or idle before the retention, lock must be
+// held
+func (t *EventTracker) cleanup(now time.Time) {
+	if now.Sub(t.cleanedAt) < time.Minute {
+		return
+	}
+	t.cleanedAt = now
+	for k, e := range t.events {
+		if (!e.closed.IsZero() && now.Sub(e.closed) > closedEventRetention) ||
+			now.Sub(e.seen) > idleEventRetention {
+			delete(t.events, k)
+		}
+	}
+}
+
+// eventState from the odds change sport event status or fixture change
+// cancellation
+func eventState(m *uof.Message) (uof.EventState, bool) {
+	s := uof.EventState{EventID: m.EventID, EventURN: m.EventURN, Producer: m.Producer, Timestamp: m.Timestamp}
+	switch {
+	case m.OddsChange != nil && m.OddsChange.EventStatus != nil:
+		es := m.OddsChange.EventStatus
+		s.Status = es.Status
+		s.MatchStatus = es.MatchStatus
+		s.HomeScore = es.HomeScore
+		s.AwayScore = es.AwayScore
+		s.Clock = es.Clock
+		return s, true
+	case m.FixtureChange != nil && m.FixtureChange.ChangeType != nil &&
+		*m.FixtureChange.ChangeType == uof.FixtureChangeTypeCancelled:
+		s.Status = uof.EventStatusCancelled
+		return s, true
+	}
+	return s, false
+}
@@ -0,0 +1,96 @@
+package pipe
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func statusMsg(t *testing.T, status, matchStatus int) *uof.Message {
+	buf := fmt.Sprintf(`<odds_change event_id="sr:match:1" timestamp="1" product="1">
+		<sport_event_status status="%d" match_status="%d" home_score="1" away_score="0"/></odds_change>`, status, matchStatus)
+	m, err := uof.NewQueueMessage("hi.-.live.odds_change.1.sr:match.1.-", []byte(buf))
+	assert.NoError(t, err)
+	return m
+}
+
+func TestEventTracker(t *testing.T) {
+	et := NewEventTracker()
+	_, ok := et.Apply(statusMsg(t, 0, 0))
+	assert.False(t, ok)
+	_, ok = et.Apply(statusMsg(t, 0, 0))
+	assert.False(t, ok)
+
+	tr, ok := et.Apply(statusMsg(t, 1, 6))
+	assert.True(t, ok)
+	assert.Equal(t, uof.EventTransitionKickoff, tr.Type)
+	assert.False(t, tr.Invalid)
+	s, _ := et.State(1, uof.ProducerLiveOdds)
+	assert.True(t, s.Live())
+	assert.Equal(t, 1, *s.HomeScore)
+
+	tr, ok = et.Apply(statusMsg(t, 1, 31))
+	assert.True(t, ok)
+	assert.Equal(t, uof.EventTransitionPeriodChange, tr.Type)
+	assert.Equal(t, 6, *tr.MatchStatusFrom)
+
+	tr, _ = et.Apply(statusMsg(t, 3, 100))
+	assert.Equal(t, uof.EventTransitionEnded, tr.Type)
+	tr, _ = et.Apply(statusMsg(t, 1, 7))
+	assert.True(t, tr.Invalid)
+
+	tr, _ = et.Apply(statusMsg(t, 4, 100))
+	assert.Equal(t, uof.EventTransitionClosed, tr.Type)
+	s, _ = et.State(1, uof.ProducerLiveOdds)
+	assert.Equal(t, uof.EventStatusClosed, s.Status)
+	_, ok = et.Apply(statusMsg(t, 4, 100))
+	assert.False(t, ok)
+	// closed is remembered, back to live is invalid
+	tr, ok = et.Apply(statusMsg(t, 1, 7))
+	assert.True(t, ok)
+	assert.True(t, tr.Invalid)
+	assert.Equal(t, uof.EventStatusClosed, tr.From)
+
+	// prematch producer state is tracked separately
+	pm, err := uof.NewQueueMessage("hi.pre.-.odds_change.1.sr:match.1.-",
+		[]byte(`<odds_change event_id="sr:match:1" timestamp="2" product="3"><sport_event_status status="0"/></odds_change>`))
+	assert.NoError(t, err)
+	_, ok = et.Apply(pm)
+	assert.False(t, ok)
+	s, _ = et.State(1, uof.ProducerPrematch)
+	assert.Equal(t, uof.EventStatusNotStarted, s.Status)
+	s, _ = et.State(1, uof.ProducerLiveOdds)
+	assert.Equal(t, uof.EventStatusLive, s.Status)
+
+	et.cleanup(time.Now().Add(2 * closedEventRetention))
+	_, ok = et.State(1, uof.ProducerLiveOdds)
+	assert.True(t, ok)
+	et.Apply(statusMsg(t, 4, 100))
+	et.cleanedAt = time.Time{}
+	et.cleanup(time.Now().Add(2 * closedEventRetention))
+	_, ok = et.State(1, uof.ProducerLiveOdds)
+	assert.False(t, ok)
+	// idle events are removed
+	_, ok = et.State(1, uof.ProducerPrematch)
+	assert.True(t, ok)
+	et.cleanedAt = time.Time{}
+	et.cleanup(time.Now().Add(idleEventRetention + time.Hour))
+	_, ok = et.State(1, uof.ProducerPrematch)
+	assert.False(t, ok)
+
+	fc, err := uof.NewQueueMessage("hi.pre.-.fixture_change.1.sr:match.2.-",
+		[]byte(`<fixture_change event_id="sr:match:2" product="3" change_type="3"/>`))
+	assert.NoError(t, err)
+	tr, ok = et.Apply(fc)
+	assert.True(t, ok)
+	assert.Equal(t, uof.EventTransitionCancelled, tr.Type)
+	assert.Equal(t, 2, tr.State.EventID)
+
+	m := uof.NewEventTransitionMessage(tr)
+	assert.Equal(t, uof.MessageKindEvent, m.Type.Kind())
+	assert.Equal(t, uof.ProducerPrematch, m.Producer)
+	assert.Equal(t, uof.MessageScopePrematch, m.Scope)
+}
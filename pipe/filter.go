@@ -0,0 +1,174 @@
+package pipe
+
+import "github.com/minus5/go-uof-sdk"
+
+// MessageFilter is declarative predicate on the message header.
+//
+// Each non empty field limits messages to those with one of the listed
+// values. Fields are combined with and. System messages (connection,
+// producers change, alive...) always match.
+// Attribute which is not set in the message does not filter it out: Lang is
+// set only for lexicon messages, Scope is checked only for event messages
+// with known producer scope, SportID, Producer and EventURN when they are
+// known.
+type MessageFilter struct {
+	Types     []uof.MessageType
+	Kinds     []uof.MessageKind
+	Producers []uof.Producer
+	Scopes    []uof.MessageScope
+	SportIDs  []int
+	EventURNs []uof.URN
+	Langs     []uof.Lang
+}
+
+// Match reports whether message passes the filter.
+func (f MessageFilter) Match(m *uof.Message) bool {
+	kind := m.Type.Kind()
+	if kind == uof.MessageKindSystem {
+		return true
+	}
+	if len(f.Types) > 0 && !containsType(f.Types, m.Type) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !containsKind(f.Kinds, kind) {
+		return false
+	}
+	if len(f.Producers) > 0 && knownProducer(m.Producer) && !containsProducer(f.Producers, m.Producer) {
+		return false
+	}
+	if len(f.Scopes) > 0 && kind == uof.MessageKindEvent && m.Scope != uof.MessageScopeSystem &&
+		!containsScope(f.Scopes, m.Scope) {
+		return false
+	}
+	if len(f.SportIDs) > 0 {
+		if id := sportID(m); id != 0 && !containsInt(f.SportIDs, id) {
+			return false
+		}
+	}
+	if len(f.EventURNs) > 0 && m.EventURN != uof.NoURN && !containsURN(f.EventURNs, m.EventURN) {
+		return false
+	}
+	if len(f.Langs) > 0 && m.Lang != uof.LangNone && !containsLang(f.Langs, m.Lang) {
+		return false
+	}
+	return true
+}
+
+// knownProducer is set in the header of event messages and lexicon messages of
+// the non sport (virtual) events. Fixture of sr:match event has default
+// producer, it is used by both live and prematch.
+func knownProducer(p uof.Producer) bool {
+	return p > uof.ProducerDefault
+}
+
+// sportID from the routing key or from the fixture
+func sportID(m *uof.Message) int {
+	if m.SportID != 0 {
+		return m.SportID
+	}
+	if m.Fixture != nil {
+		return m.Fixture.Sport.ID
+	}
+	if m.Tournament != nil {
+		return m.Tournament.Sport.ID
+	}
+	return 0
+}
+
+// Filter passes to the next stages only messages matching filter. Other
+// messages are dropped from the pipeline, use FilteredConsumer to filter for
+// one consumer only.
+func Filter(f MessageFilter) InnerStage {
+	return Stage(func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+		for m := range in {
+			if f.Match(m) {
+				out <- m
+			}
+		}
+	})
+}
+
+// FilteredConsumer wraps consumer so it receives only messages matching
+// filter. Other consumers in the pipeline are not affected.
+func FilteredConsumer(f MessageFilter, consumer ConsumerStage) ConsumerStage {
+	return func(in <-chan *uof.Message) error {
+		filtered := make(chan *uof.Message)
+		go func() {
+			defer close(filtered)
+			for m := range in {
+				if f.Match(m) {
+					filtered <- m
+				}
+			}
+		}()
+		err := consumer(filtered)
+		go func() { // for unclean exit; drain this chan
+			for range filtered {
+			}
+		}()
+		return err
+	}
+}
+
+func containsType(a []uof.MessageType, v uof.MessageType) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKind(a []uof.MessageKind, v uof.MessageKind) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsProducer(a []uof.Producer, v uof.Producer) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsScope(a []uof.MessageScope, v uof.MessageScope) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(a []int, v int) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsURN(a []uof.URN, v uof.URN) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLang(a []uof.Lang, v uof.Lang) bool {
+	for _, e := range a {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
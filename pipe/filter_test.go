@@ -0,0 +1,60 @@
+package pipe
+
+import (
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageFilter(t *testing.T) {
+	oc := oddsChangeMessage(t) // prematch, sport 1, sr:match:1234
+	fc := fixtureChangeMsg(t)  // prematch, sport 1, sr:match:1234
+	cm := uof.NewConnnectionMessage(uof.ConnectionStatusUp)
+	pm := uof.NewPlayerMessage(uof.LangDE, &uof.Player{ID: 1}, 0)
+	fm := uof.NewFixtureMessage(uof.LangEN, uof.Fixture{URN: "sr:match:4321", Sport: uof.Sport{ID: 2}}, 0)
+	vm := uof.NewFixtureMessage(uof.LangEN, uof.Fixture{URN: "vf:match:1"}, 0)
+
+	data := []struct {
+		filter  MessageFilter
+		matches []bool // oc, fc, cm, pm, fm
+	}{
+		{MessageFilter{}, []bool{true, true, true, true, true}},
+		{MessageFilter{Types: []uof.MessageType{uof.MessageTypeOddsChange}}, []bool{true, false, true, false, false}},
+		{MessageFilter{Kinds: []uof.MessageKind{uof.MessageKindLexicon}}, []bool{false, false, true, true, true}},
+		{MessageFilter{Producers: []uof.Producer{uof.ProducerLiveOdds}}, []bool{false, false, true, true, true}},
+		{MessageFilter{Scopes: []uof.MessageScope{uof.MessageScopeLive}}, []bool{false, false, true, true, true}},
+		{MessageFilter{SportIDs: []int{1}}, []bool{true, true, true, true, false}},
+		{MessageFilter{EventURNs: []uof.URN{"sr:match:4321"}}, []bool{false, false, true, true, true}},
+		{MessageFilter{Langs: []uof.Lang{uof.LangEN}}, []bool{true, true, true, false, true}},
+		{MessageFilter{Types: []uof.MessageType{uof.MessageTypeOddsChange}, SportIDs: []int{2}}, []bool{false, false, true, false, false}},
+	}
+	for i, d := range data {
+		for j, m := range []*uof.Message{oc, fc, cm, pm, fm} {
+			assert.Equal(t, d.matches[j], d.filter.Match(m), "filter %d message %d", i, j)
+		}
+	}
+	// producer of the virtual event fixture is known
+	assert.False(t, MessageFilter{Producers: []uof.Producer{uof.ProducerLiveOdds}}.Match(vm))
+	assert.True(t, MessageFilter{Producers: []uof.Producer{6}}.Match(vm))
+}
+
+func TestFilteredConsumer(t *testing.T) {
+	f := MessageFilter{Types: []uof.MessageType{uof.MessageTypeFixtureChange}}
+	var received []*uof.Message
+	consumer := FilteredConsumer(f, func(in <-chan *uof.Message) error {
+		for m := range in {
+			received = append(received, m)
+		}
+		return nil
+	})
+	in := make(chan *uof.Message, 3)
+	in <- oddsChangeMessage(t)
+	in <- fixtureChangeMsg(t)
+	in <- uof.NewConnnectionMessage(uof.ConnectionStatusUp)
+	close(in)
+	assert.NoError(t, consumer(in))
+	assert.Len(t, received, 2)
+	assert.Equal(t, uof.MessageTypeFixtureChange, received[0].Type)
+	assert.Equal(t, uof.MessageTypeConnection, received[1].Type)
+}
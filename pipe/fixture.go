@@ -14,19 +14,92 @@ type fixtureAPI interface {
 	Fixtures(lang uof.Lang, to time.Time) (<-chan uof.Fixture, <-chan error)
 }
 
+// fixtureCacheAPI is used to preload live events and schedule by date, and
+// to find fixtures changed since the cache sync
+type fixtureCacheAPI interface {
+	FixtureChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error)
+	Schedule(lang uof.Lang, date time.Time) ([]uof.Fixture, error)
+	LiveSchedule(lang uof.Lang) ([]uof.Fixture, error)
+}
+
+// PreloadConfig configures fixtures preload on start.
+type PreloadConfig struct {
+	// From and To limit scheduled time of the preloaded fixtures. Zero From
+	// is without lower limit, zero To disables preload.
+	From time.Time
+	To   time.Time
+	// Sports ids to preload, empty for all sports.
+	Sports []int
+	// CacheDir of the persistent fixture cache. With the cache only fixtures
+	// changed since the last preload are fetched on restart (api has to
+	// support fixture changes), others are read from the disk.
+	CacheDir string
+}
+
+// selected reports whether fixture is in the preload window and sports
+func (c PreloadConfig) selected(x uof.Fixture) bool {
+	if !x.Scheduled.IsZero() {
+		if !c.From.IsZero() && x.Scheduled.Before(c.From) {
+			return false
+		}
+		if x.Scheduled.After(c.To) {
+			return false
+		}
+	}
+	if len(c.Sports) == 0 {
+		return true
+	}
+	for _, id := range c.Sports {
+		if x.Sport.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// FixtureConfig configures fixture stage.
+type FixtureConfig struct {
+	Preload  PreloadConfig
+	Policies FixturePolicies
+}
+
 type fixture struct {
 	api       fixtureAPI
 	languages []uof.Lang // suported languages
 	em        *expireMap
 	errc      chan<- error
 	out       chan<- *uof.Message
-	preloadTo time.Time
+	preload   PreloadConfig
+	policies  FixturePolicies
+	cache     *fixtureCache
 	subProcs  *sync.WaitGroup
 	rateLimit chan struct{}
+
+	fetched  map[int]time.Time       // last fetch of the event fixture triggered by message
+	pruned   time.Time               // last cleanup of fetched
+	timers   map[uof.URN]*time.Timer // scheduled refreshes
+	trailing map[uof.URN]*time.Timer // fetches after throttle interval
+	stopped  bool
 	sync.Mutex
 }
 
 func Fixture(api fixtureAPI, languages []uof.Lang, preloadTo time.Time) InnerStage {
+	return FixtureStage(api, languages, FixtureConfig{Preload: PreloadConfig{To: preloadTo}})
+}
+
+// FixturePreload is Fixture stage with preload window, sport filter and
+// persistent fixture cache.
+func FixturePreload(api fixtureAPI, languages []uof.Lang, cfg PreloadConfig) InnerStage {
+	return FixtureStage(api, languages, FixtureConfig{Preload: cfg})
+}
+
+// FixtureStage fetches fixtures on fixture change and bet stop messages, with
+// preload on start and fetching policy for each producer.
+func FixtureStage(api fixtureAPI, languages []uof.Lang, cfg FixtureConfig) InnerStage {
+	return StageWithSubProcessesSync(newFixture(api, languages, cfg).loop)
+}
+
+func newFixture(api fixtureAPI, languages []uof.Lang, cfg FixtureConfig) *fixture {
 	f := &fixture{
 		api:       api,
 		languages: languages,
@@ -34,9 +107,16 @@ func Fixture(api fixtureAPI, languages []uof.Lang, preloadTo time.Time) InnerSta
 		//requests:  make(map[string]time.Time),
 		subProcs:  &sync.WaitGroup{},
 		rateLimit: make(chan struct{}, ConcurentAPICallsLimit),
-		preloadTo: preloadTo,
+		preload:   cfg.Preload,
+		policies:  cfg.Policies,
+		fetched:   make(map[int]time.Time),
+		timers:    make(map[uof.URN]*time.Timer),
+		trailing:  make(map[uof.URN]*time.Timer),
+	}
+	if cfg.Preload.CacheDir != "" {
+		f.cache = newFixtureCache(cfg.Preload.CacheDir)
 	}
-	return StageWithSubProcessesSync(f.loop)
+	return f
 }
 
 // Na sto sve pazim ovdje:
@@ -49,20 +129,148 @@ func Fixture(api fixtureAPI, languages []uof.Lang, preloadTo time.Time) InnerSta
 func (f *fixture) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) *sync.WaitGroup {
 	f.errc, f.out = errc, out
 
-	for _, u := range f.preloadLoop(in) {
-		f.getFixture(u, uof.CurrentTimestamp(), true)
+	for _, m := range f.preloadLoop(in) {
+		// skip fixtures fetched by preload, unless change is urgent
+		skipPreloaded := !f.policies.policy(m.Producer).urgent(m)
+		f.getFixture(f.eventURN(m), m.ReceivedAt, skipPreloaded, m.Producer)
 	}
 	for m := range in {
 		out <- m
-		if u := f.eventURN(m); u != uof.NoURN {
-			u.IsTournament()
-			f.getFixture(u, m.ReceivedAt, false)
+		if u := f.finished(m); u != uof.NoURN {
+			f.unschedule(u)
+		}
+		if u := f.eventURN(m); u != uof.NoURN && f.throttle(u, m) {
+			f.getFixture(u, m.ReceivedAt, false, m.Producer)
 		}
 	}
-
+	f.stop()
 	return f.subProcs
 }
 
+// throttle reports whether fixture should be fetched for the message. When
+// fetch is throttled trailing fetch is scheduled at the end of throttle
+// interval, so that change is not missed.
+func (f *fixture) throttle(eventURN uof.URN, m *uof.Message) bool {
+	p := f.policies.policy(m.Producer)
+	if at, ok := f.throttled(eventURN, m, p); ok {
+		f.scheduleAt(f.trailing, eventURN, at, m.Producer)
+		return false
+	}
+	return true
+}
+
+// throttled returns time of the trailing fetch if fetch is throttled
+func (f *fixture) throttled(eventURN uof.URN, m *uof.Message, p FixturePolicy) (time.Time, bool) {
+	now := time.Now()
+	id := eventURN.EventID()
+
+	f.Lock()
+	defer f.Unlock()
+	if max := f.policies.maxThrottle(); now.Sub(f.pruned) > max {
+		for k, t := range f.fetched {
+			if now.Sub(t) > max {
+				delete(f.fetched, k)
+			}
+		}
+		f.pruned = now
+	}
+	if t, ok := f.fetched[id]; ok && now.Sub(t) < p.Throttle && !p.urgent(m) {
+		return t.Add(p.Throttle), true
+	}
+	f.fetched[id] = now
+	return time.Time{}, false
+}
+
+// finished returns urn of the event closed or cancelled by the message
+func (f *fixture) finished(m *uof.Message) uof.URN {
+	switch {
+	case m.OddsChange != nil && m.OddsChange.EventStatus != nil:
+		switch m.OddsChange.EventStatus.Status {
+		case uof.EventStatusEnded, uof.EventStatusClosed, uof.EventStatusCancelled, uof.EventStatusAbandoned:
+			return m.EventURN
+		}
+	case m.FixtureChange != nil && m.FixtureChange.ChangeType != nil &&
+		*m.FixtureChange.ChangeType == uof.FixtureChangeTypeCancelled:
+		return m.FixtureChange.EventURN
+	}
+	return uof.NoURN
+}
+
+// refresh schedules next fetch of the fetched fixture, once for all languages
+func (f *fixture) refresh(lang uof.Lang, eventURN uof.URN, x uof.Fixture, producer uof.Producer) {
+	if lang != f.languages[0] {
+		return
+	}
+	switch x.Status {
+	case "ended", "closed", "cancelled", "abandoned":
+		f.unschedule(eventURN)
+		return
+	}
+	f.schedule(eventURN, startTime(x), x.NextLiveTime, producer)
+}
+
+// schedule next fixture refresh, replaces previously scheduled one
+func (f *fixture) schedule(eventURN uof.URN, start, nextLive time.Time, producer uof.Producer) {
+	now := time.Now()
+	at := f.policies.policy(producer).nextRefresh(eventURN, start, nextLive, now)
+	if at.IsZero() {
+		f.unschedule(eventURN)
+		return
+	}
+	f.scheduleAt(f.timers, eventURN, at, producer)
+}
+
+// scheduleAt schedules fixture fetch at, replaces previously scheduled one in
+// timers
+func (f *fixture) scheduleAt(timers map[uof.URN]*time.Timer, eventURN uof.URN, at time.Time, producer uof.Producer) {
+	now := time.Now()
+	f.Lock()
+	defer f.Unlock()
+	if f.stopped {
+		return
+	}
+	if t, ok := timers[eventURN]; ok {
+		t.Stop()
+	} else if len(timers) >= maxFixtureRefreshes {
+		return
+	}
+	timers[eventURN] = time.AfterFunc(at.Sub(now), func() {
+		f.Lock()
+		if f.stopped {
+			f.Unlock()
+			return
+		}
+		delete(timers, eventURN)
+		// added under lock, so that stage can't finish before refresh
+		f.subProcs.Add(1)
+		f.Unlock()
+		defer f.subProcs.Done()
+		f.getFixture(eventURN, uof.CurrentTimestamp(), false, producer)
+	})
+}
+
+func (f *fixture) unschedule(eventURN uof.URN) {
+	f.Lock()
+	defer f.Unlock()
+	if t, ok := f.timers[eventURN]; ok {
+		t.Stop()
+		delete(f.timers, eventURN)
+	}
+}
+
+// stop all scheduled refreshes and trailing fetches
+func (f *fixture) stop() {
+	f.Lock()
+	defer f.Unlock()
+	f.stopped = true
+	for _, t := range f.timers {
+		t.Stop()
+	}
+	for _, t := range f.trailing {
+		t.Stop()
+	}
+}
+
 func (f *fixture) eventURN(m *uof.Message) uof.URN {
 	if m.Producer.Virtuals() && m.Is(uof.MessageTypeOddsChange) {
 		return m.EventURN
@@ -80,36 +288,39 @@ func (f *fixture) eventURN(m *uof.Message) uof.URN {
 	return uof.NoURN
 }
 
-// returns list of fixture changes urns appeared in 'in' during preload
-func (f *fixture) preloadLoop(in <-chan *uof.Message) []uof.URN {
+// returns messages which require fixture fetch appeared in 'in' during preload
+func (f *fixture) preloadLoop(in <-chan *uof.Message) []*uof.Message {
 	done := make(chan struct{})
 
 	f.subProcs.Add(1)
 	go func() {
 		defer f.subProcs.Done()
-		f.preload()
+		f.preloadAll()
 		close(done)
 	}()
 
-	var urns []uof.URN
+	var ms []*uof.Message
 	for {
 		select {
 		case m, ok := <-in:
 			if !ok {
-				return urns
+				return ms
 			}
 			f.out <- m
-			if u := f.eventURN(m); u != uof.NoURN {
-				urns = append(urns, u)
+			if u := f.finished(m); u != uof.NoURN {
+				f.unschedule(u)
+			}
+			if u := f.eventURN(m); u != uof.NoURN && f.throttle(u, m) {
+				ms = append(ms, m)
 			}
 		case <-done:
-			return urns
+			return ms
 		}
 	}
 }
 
-func (f *fixture) preload() {
-	if f.preloadTo.IsZero() {
+func (f *fixture) preloadAll() {
+	if f.preload.To.IsZero() {
 		return
 	}
 	var wg sync.WaitGroup
@@ -117,20 +328,186 @@ func (f *fixture) preload() {
 	for _, lang := range f.languages {
 		go func(lang uof.Lang) {
 			defer wg.Done()
-			in, errc := f.api.Fixtures(lang, f.preloadTo)
-			for x := range in {
-				f.out <- uof.NewFixtureMessage(lang, x, uof.CurrentTimestamp())
-				f.em.insert(uof.UIDWithLang(x.URN.EventID(), lang))
-			}
-			for err := range errc {
-				f.errc <- err
-			}
+			f.preloadLang(lang)
 		}(lang)
 	}
 	wg.Wait()
 }
 
-func (f *fixture) getFixture(eventURN uof.URN, receivedAt int, isPreload bool) {
+func (f *fixture) preloadLang(lang uof.Lang) {
+	start := time.Now()
+	sa, byDate := f.api.(fixtureCacheAPI)
+	if f.cache != nil && byDate {
+		s := f.cache.synced(lang)
+		if time.Since(s.At) < fixtureChangesMaxAge {
+			sent, err := f.preloadCached(lang, sa, s)
+			if err == nil {
+				f.setSynced(lang, start)
+				return
+			}
+			f.errc <- err
+			if sent {
+				// full preload would send the same fixtures again
+				return
+			}
+		}
+	}
+
+	var err error
+	if byDate {
+		// live events, they could be scheduled before today, then schedule
+		// by date from the start of the preload window
+		var live map[uof.URN]struct{}
+		live, err = f.preloadLive(lang, sa)
+		if err == nil {
+			from := start.Truncate(24 * time.Hour)
+			if f.preload.From.After(from) {
+				from = f.preload.From
+			}
+			_, err = f.preloadSchedule(lang, sa, from, f.preload.To, live)
+		}
+	} else {
+		err = f.preloadFixtures(lang)
+	}
+	if err != nil {
+		f.errc <- err
+		return
+	}
+	f.setSynced(lang, start)
+}
+
+// preloadFixtures pages through the whole schedule until preload To, for
+// api without schedule by date
+func (f *fixture) preloadFixtures(lang uof.Lang) error {
+	var first error
+	in, errc := f.api.Fixtures(lang, f.preload.To)
+	for x := range in {
+		f.preloaded(lang, x, true)
+	}
+	for err := range errc {
+		if first == nil {
+			first = err
+			continue
+		}
+		f.errc <- err
+	}
+	return first
+}
+
+// preloadLive sends fixtures of the live events. Returns their urns.
+func (f *fixture) preloadLive(lang uof.Lang, api fixtureCacheAPI) (map[uof.URN]struct{}, error) {
+	xs, err := api.LiveSchedule(lang)
+	if err != nil {
+		return nil, err
+	}
+	sent := make(map[uof.URN]struct{})
+	for _, x := range xs {
+		f.preloaded(lang, x, true)
+		sent[x.URN] = struct{}{}
+	}
+	return sent, nil
+}
+
+// preloadSchedule fetches schedule for each date in the window and sends
+// fixtures scheduled from the start of the window, except already sent ones.
+// Returns number of sent fixtures.
+func (f *fixture) preloadSchedule(lang uof.Lang, api fixtureCacheAPI, from, to time.Time, sent map[uof.URN]struct{}) (int, error) {
+	n := 0
+	for d := from.Truncate(24 * time.Hour); !d.After(to); d = d.Add(24 * time.Hour) {
+		xs, err := api.Schedule(lang, d)
+		if err != nil {
+			return n, err
+		}
+		for _, x := range xs {
+			if !x.Scheduled.IsZero() && x.Scheduled.Before(from) {
+				continue
+			}
+			if _, ok := sent[x.URN]; ok {
+				continue
+			}
+			f.preloaded(lang, x, true)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// preloadCached sends cached fixtures and fetches those changed since the
+// last sync, or scheduled in the part of the window not covered by the sync.
+// Reports whether any fixture is sent before error.
+func (f *fixture) preloadCached(lang uof.Lang, api fixtureCacheAPI, s fixtureCacheSync) (bool, error) {
+	changes, err := api.FixtureChanges(lang, s.At)
+	if err != nil {
+		return false, err
+	}
+	xs, err := f.cache.all(lang)
+	if err != nil {
+		return false, err
+	}
+	changed := make(map[uof.URN]struct{})
+	for _, c := range changes {
+		changed[c.EventURN] = struct{}{}
+	}
+	sent := false
+	expired := time.Now().Add(-fixtureCacheRetention)
+	for _, x := range xs {
+		if _, ok := changed[x.URN]; ok {
+			continue
+		}
+		if !x.Scheduled.IsZero() && x.Scheduled.Before(expired) {
+			f.cache.remove(lang, x.URN)
+			continue
+		}
+		f.preloaded(lang, x, false)
+		sent = true
+	}
+	for u := range changed {
+		x, err := f.api.Fixture(lang, u)
+		if err != nil {
+			f.errc <- err
+			continue
+		}
+		f.preloaded(lang, *x, true)
+		sent = true
+	}
+	// window extended after the last sync
+	if f.preload.To.After(s.To) {
+		n, err := f.preloadSchedule(lang, api, s.To, f.preload.To, nil)
+		if err != nil {
+			return sent || n > 0, err
+		}
+	}
+	return sent, nil
+}
+
+// preloaded sends fixture if selected by the preload config, fetched fixture
+// is also stored in the cache
+func (f *fixture) preloaded(lang uof.Lang, x uof.Fixture, fetched bool) {
+	if fetched && f.cache != nil {
+		if err := f.cache.put(lang, x); err != nil {
+			f.errc <- err
+		}
+	}
+	if !f.preload.selected(x) {
+		return
+	}
+	f.refresh(lang, x.URN, x, uof.ProducerUnknown)
+	f.out <- uof.NewFixtureMessage(lang, x, uof.CurrentTimestamp())
+	f.em.insert(uof.UIDWithLang(x.URN.EventID(), lang))
+}
+
+func (f *fixture) setSynced(lang uof.Lang, at time.Time) {
+	if f.cache == nil {
+		return
+	}
+	if err := f.cache.setSynced(lang, fixtureCacheSync{At: at, To: f.preload.To}); err != nil {
+		f.errc <- err
+	}
+}
+
+// getFixture fetches fixture in all languages, skipPreloaded skips fixtures
+// sent by preload in the last minute
+func (f *fixture) getFixture(eventURN uof.URN, receivedAt int, skipPreloaded bool, producer uof.Producer) {
 	f.subProcs.Add(len(f.languages))
 	for _, lang := range f.languages {
 		go func(lang uof.Lang) {
@@ -138,8 +515,7 @@ func (f *fixture) getFixture(eventURN uof.URN, receivedAt int, isPreload bool) {
 			f.rateLimit <- struct{}{}
 			defer func() { <-f.rateLimit }()
 
-			key := uof.UIDWithLang(eventURN.EventID(), lang)
-			if isPreload && f.em.fresh(key) {
+			if skipPreloaded && f.em.fresh(uof.UIDWithLang(eventURN.EventID(), lang)) {
 				return
 			}
 			if eventURN.IsTournament() {
@@ -148,6 +524,9 @@ func (f *fixture) getFixture(eventURN uof.URN, receivedAt int, isPreload bool) {
 					f.errc <- err
 					return
 				}
+				if lang == f.languages[0] {
+					f.schedule(eventURN, time.Time{}, time.Time{}, producer)
+				}
 				f.out <- uof.NewTournamentMessage(lang, *x, receivedAt)
 			} else if eventURN.Producer().Virtuals() {
 				x, err := f.api.Summary(lang, eventURN)
@@ -162,9 +541,21 @@ func (f *fixture) getFixture(eventURN uof.URN, receivedAt int, isPreload bool) {
 					f.errc <- err
 					return
 				}
+				if f.cache != nil {
+					if err := f.cache.put(lang, *x); err != nil {
+						f.errc <- err
+					}
+				}
+				f.refresh(lang, eventURN, *x, producer)
 				f.out <- uof.NewFixtureMessage(lang, *x, receivedAt)
 			}
-			f.em.insert(key)
 		}(lang)
 	}
 }
+
+func startTime(x uof.Fixture) time.Time {
+	if !x.StartTime.IsZero() {
+		return x.StartTime
+	}
+	return x.Scheduled
+}
@@ -0,0 +1,150 @@
+package pipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+const (
+	// fixture changes api lists changes in the last 24 hours, older cache
+	// has to be fully reloaded
+	fixtureChangesMaxAge = 24 * time.Hour
+	// cached fixtures scheduled before are removed
+	fixtureCacheRetention = 48 * time.Hour
+	fixtureCacheSyncFile  = "sync.json"
+)
+
+// fixtureCache persists fixtures on disk, one json file for each fixture and
+// language. Fixture is replaced only with the one generated later.
+type fixtureCache struct {
+	dir string
+	sync.Mutex
+}
+
+// fixtureCacheSync state of the last successful preload
+type fixtureCacheSync struct {
+	At time.Time `json:"at"` // start of the preload
+	To time.Time `json:"to"` // end of the preload window
+}
+
+func newFixtureCache(dir string) *fixtureCache {
+	return &fixtureCache{dir: dir}
+}
+
+func (c *fixtureCache) langDir(lang uof.Lang) string {
+	return filepath.Join(c.dir, lang.Code())
+}
+
+func (c *fixtureCache) filename(lang uof.Lang, eventURN uof.URN) string {
+	return filepath.Join(c.langDir(lang), strings.Replace(string(eventURN), ":", "_", -1)+".json")
+}
+
+// get does not lock, it is used by put under the lock and files are always
+// replaced whole
+func (c *fixtureCache) get(lang uof.Lang, eventURN uof.URN) (uof.Fixture, bool) {
+	var x uof.Fixture
+	buf, err := ioutil.ReadFile(c.filename(lang, eventURN))
+	if err != nil {
+		return x, false
+	}
+	if err := json.Unmarshal(buf, &x); err != nil {
+		return x, false
+	}
+	return x, true
+}
+
+func (c *fixtureCache) put(lang uof.Lang, x uof.Fixture) error {
+	c.Lock()
+	defer c.Unlock()
+	if prev, ok := c.get(lang, x.URN); ok && prev.GeneratedAt.After(x.GeneratedAt) {
+		return nil
+	}
+	buf, err := json.Marshal(x)
+	if err != nil {
+		return uof.E("fixture cache", err)
+	}
+	return c.write(c.filename(lang, x.URN), buf)
+}
+
+func (c *fixtureCache) remove(lang uof.Lang, eventURN uof.URN) {
+	c.Lock()
+	defer c.Unlock()
+	os.Remove(c.filename(lang, eventURN))
+}
+
+// all cached fixtures of the language
+func (c *fixtureCache) all(lang uof.Lang) ([]uof.Fixture, error) {
+	c.Lock()
+	defer c.Unlock()
+	fis, err := ioutil.ReadDir(c.langDir(lang))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, uof.E("fixture cache", err)
+	}
+	var xs []uof.Fixture
+	for _, fi := range fis {
+		if fi.IsDir() || fi.Name() == fixtureCacheSyncFile || filepath.Ext(fi.Name()) != ".json" {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(c.langDir(lang), fi.Name()))
+		if err != nil {
+			return nil, uof.E("fixture cache", err)
+		}
+		var x uof.Fixture
+		if err := json.Unmarshal(buf, &x); err != nil {
+			// skip corrupted file, it will be fetched again
+			os.Remove(filepath.Join(c.langDir(lang), fi.Name()))
+			continue
+		}
+		xs = append(xs, x)
+	}
+	return xs, nil
+}
+
+func (c *fixtureCache) synced(lang uof.Lang) fixtureCacheSync {
+	c.Lock()
+	defer c.Unlock()
+	var s fixtureCacheSync
+	buf, err := ioutil.ReadFile(filepath.Join(c.langDir(lang), fixtureCacheSyncFile))
+	if err != nil {
+		return s
+	}
+	_ = json.Unmarshal(buf, &s)
+	return s
+}
+
+func (c *fixtureCache) setSynced(lang uof.Lang, s fixtureCacheSync) error {
+	c.Lock()
+	defer c.Unlock()
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return uof.E("fixture cache", err)
+	}
+	return c.write(filepath.Join(c.langDir(lang), fixtureCacheSyncFile), buf)
+}
+
+// write replaces file content, through temporary file so that it is never
+// left half written
+func (c *fixtureCache) write(filename string, buf []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return uof.E("fixture cache", err)
+	}
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return uof.E("fixture cache", err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		return uof.E("fixture cache", err)
+	}
+	return nil
+}
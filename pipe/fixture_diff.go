@@ -0,0 +1,110 @@
+package pipe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// fixtureDiffRetention is how long fixture is kept after it is last seen or
+// after its start time, whichever is later
+const fixtureDiffRetention = 48 * time.Hour
+
+type fixtureDiffEntry struct {
+	fixture uof.Fixture
+	seen    time.Time
+}
+
+// expired reports whether entry is out of retention
+func (e fixtureDiffEntry) expired(now time.Time) bool {
+	last := e.seen
+	if s := e.fixture.StartTime; s.After(last) {
+		last = s
+	}
+	if s := e.fixture.Scheduled; s.After(last) {
+		last = s
+	}
+	return now.Sub(last) > fixtureDiffRetention
+}
+
+// FixtureDiffs keeps the last fixture of each event and language and finds
+// changes when new version of the fixture arrives.
+//
+// First seen fixture is recorded without diff. Fixture generated before the
+// recorded one is ignored. Events are removed when fixture status is closed
+// or cancelled, or when they are out of retention.
+type FixtureDiffs struct {
+	fixtures  map[int]fixtureDiffEntry // by event id with language
+	cleanedAt time.Time
+	sync.Mutex
+}
+
+// NewFixtureDiffs creates empty fixture store.
+func NewFixtureDiffs() *FixtureDiffs {
+	return &FixtureDiffs{fixtures: make(map[int]fixtureDiffEntry)}
+}
+
+// Stage of the pipeline which sends fixture diff message after each fixture
+// message which changes any of the tracked fields.
+func (d *FixtureDiffs) Stage() InnerStage {
+	return Stage(func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+		for m := range in {
+			fd, ok := d.Apply(m)
+			out <- m
+			if ok {
+				out <- uof.NewFixtureDiffMessage(fd, m.RequestedAt)
+			}
+		}
+	})
+}
+
+// Fixture returns the last known fixture of the event in the language.
+func (d *FixtureDiffs) Fixture(eventID int, lang uof.Lang) (uof.Fixture, bool) {
+	d.Lock()
+	defer d.Unlock()
+	e, ok := d.fixtures[uof.UIDWithLang(eventID, lang)]
+	return e.fixture, ok
+}
+
+// Apply fixture message. Returns diff to the previous version of the fixture.
+func (d *FixtureDiffs) Apply(m *uof.Message) (uof.FixtureDiff, bool) {
+	if !m.Is(uof.MessageTypeFixture) || m.Fixture == nil {
+		return uof.FixtureDiff{}, false
+	}
+	cur := *m.Fixture
+	key := uof.UIDWithLang(cur.URN.EventID(), m.Lang)
+	now := time.Now()
+
+	d.Lock()
+	defer d.Unlock()
+	d.cleanup(now)
+	e, found := d.fixtures[key]
+	prev := e.fixture
+	if found && !cur.GeneratedAt.IsZero() && cur.GeneratedAt.Before(prev.GeneratedAt) {
+		return uof.FixtureDiff{}, false
+	}
+	switch cur.Status {
+	case "closed", "cancelled":
+		delete(d.fixtures, key)
+	default:
+		d.fixtures[key] = fixtureDiffEntry{fixture: cur, seen: now}
+	}
+	if !found {
+		return uof.FixtureDiff{}, false
+	}
+	return uof.NewFixtureDiff(m.Lang, prev, cur)
+}
+
+// cleanup removes fixtures out of retention, at most once an hour
+func (d *FixtureDiffs) cleanup(now time.Time) {
+	if now.Sub(d.cleanedAt) < time.Hour {
+		return
+	}
+	d.cleanedAt = now
+	for k, e := range d.fixtures {
+		if e.expired(now) {
+			delete(d.fixtures, k)
+		}
+	}
+}
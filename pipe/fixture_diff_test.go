@@ -0,0 +1,112 @@
+package pipe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixtureDiffs(t *testing.T) {
+	start := time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC)
+	x := uof.Fixture{
+		URN:         "sr:match:1",
+		ID:          1,
+		StartTime:   start,
+		Status:      "not_started",
+		Liveodds:    "not_booked",
+		Competitors: []uof.Competitor{{URN: "sr:competitor:1"}, {URN: "sr:competitor:2"}},
+		GeneratedAt: start.Add(-time.Hour),
+	}
+	fd := NewFixtureDiffs()
+	_, ok := fd.Apply(uof.NewFixtureMessage(uof.LangEN, x, 0))
+	assert.False(t, ok)
+	_, ok = fd.Apply(uof.NewFixtureMessage(uof.LangEN, x, 0))
+	assert.False(t, ok)
+
+	y := x
+	y.StartTime = start.Add(time.Hour)
+	y.Liveodds = "booked"
+	y.TvChannels = []uof.TvChannel{{Name: "Sky"}}
+	y.GeneratedAt = x.GeneratedAt.Add(time.Minute)
+	d, ok := fd.Apply(uof.NewFixtureMessage(uof.LangEN, y, 0))
+	assert.True(t, ok)
+	assert.Len(t, d.Changes, 3)
+	c, ok := d.Change(uof.FixtureFieldStartTime)
+	assert.True(t, ok)
+	assert.Equal(t, "2020-01-02T15:00:00Z", c.From)
+	assert.Equal(t, "2020-01-02T16:00:00Z", c.To)
+	assert.True(t, d.Changed(uof.FixtureFieldLiveodds))
+	assert.True(t, d.Changed(uof.FixtureFieldTvChannels))
+	assert.False(t, d.Changed(uof.FixtureFieldCompetitors))
+
+	// other language is tracked separately
+	_, ok = fd.Apply(uof.NewFixtureMessage(uof.LangDE, x, 0))
+	assert.False(t, ok)
+
+	// older fixture is ignored
+	_, ok = fd.Apply(uof.NewFixtureMessage(uof.LangEN, x, 0))
+	assert.False(t, ok)
+	cur, _ := fd.Fixture(1, uof.LangEN)
+	assert.Equal(t, y.StartTime, cur.StartTime)
+
+	z := y
+	z.Status = "cancelled"
+	z.ReplacedBy = "sr:match:2"
+	z.Competitors = []uof.Competitor{{URN: "sr:competitor:1"}, {URN: "sr:competitor:3"}}
+	d, ok = fd.Apply(uof.NewFixtureMessage(uof.LangEN, z, 0))
+	assert.True(t, ok)
+	assert.Equal(t, []uof.FixtureField{uof.FixtureFieldStatus, uof.FixtureFieldCompetitors, uof.FixtureFieldReplacedBy},
+		[]uof.FixtureField{d.Changes[0].Field, d.Changes[1].Field, d.Changes[2].Field})
+	c, _ = d.Change(uof.FixtureFieldCompetitors)
+	assert.Equal(t, "sr:competitor:1,sr:competitor:2", c.From)
+	_, ok = fd.Fixture(1, uof.LangEN)
+	assert.False(t, ok)
+
+	m := uof.NewFixtureDiffMessage(d, 0)
+	assert.Equal(t, uof.MessageTypeFixtureDiff, m.Type)
+	assert.Equal(t, uof.MessageKindEvent, m.Type.Kind())
+	assert.True(t, MessageFilter{EventURNs: []uof.URN{"sr:match:1"}, Scopes: []uof.MessageScope{uof.MessageScopeLive}}.Match(m))
+	assert.False(t, MessageFilter{EventURNs: []uof.URN{"sr:match:2"}}.Match(m))
+	assert.Equal(t, uof.LangEN, m.Lang)
+	assert.Equal(t, 1, m.EventID)
+}
+
+func TestFixtureDiffsFromSchedule(t *testing.T) {
+	start := time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC)
+	// preloaded from the schedule
+	x := uof.Fixture{URN: "sr:match:1", ID: 1, Scheduled: start, Status: "not_started"}
+	fd := NewFixtureDiffs()
+	_, ok := fd.Apply(uof.NewFixtureMessage(uof.LangEN, x, 0))
+	assert.False(t, ok)
+
+	// fixture fetch with the same start time and tv channels
+	y := x
+	y.StartTime = start
+	y.TvChannels = []uof.TvChannel{{Name: "Sky"}}
+	y.Venue = uof.Venue{ID: 1}
+	_, ok = fd.Apply(uof.NewFixtureMessage(uof.LangEN, y, 0))
+	assert.False(t, ok)
+
+	// moved scheduled time is reported
+	z := x
+	z.Scheduled = start.Add(time.Hour)
+	fd = NewFixtureDiffs()
+	fd.Apply(uof.NewFixtureMessage(uof.LangEN, x, 0))
+	d, ok := fd.Apply(uof.NewFixtureMessage(uof.LangEN, z, 0))
+	assert.True(t, ok)
+	assert.Equal(t, []uof.FixtureFieldChange{{Field: uof.FixtureFieldStartTime, From: "2020-01-02T15:00:00Z", To: "2020-01-02T16:00:00Z"}}, d.Changes)
+}
+
+func TestFixtureDiffsRetention(t *testing.T) {
+	now := time.Now()
+	fd := NewFixtureDiffs()
+	fd.fixtures[1] = fixtureDiffEntry{seen: now.Add(-3 * fixtureDiffRetention)}
+	fd.fixtures[2] = fixtureDiffEntry{seen: now.Add(-3 * fixtureDiffRetention), fixture: uof.Fixture{Scheduled: now.Add(time.Hour)}}
+	fd.fixtures[3] = fixtureDiffEntry{seen: now}
+	fd.cleanup(now)
+	assert.Len(t, fd.fixtures, 2)
+	_, ok := fd.fixtures[1]
+	assert.False(t, ok)
+}
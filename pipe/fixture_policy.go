@@ -0,0 +1,104 @@
+package pipe
+
+import (
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// maxFixtureRefreshes limits number of scheduled fixture refreshes, new
+// refreshes are not scheduled over the limit
+const maxFixtureRefreshes = 100000
+
+// FixturePolicy decides when fixture of the event is fetched.
+type FixturePolicy struct {
+	// Throttle is minimal interval between two fetches of the same fixture
+	// triggered by messages. Zero is one minute.
+	Throttle time.Duration
+	// Urgent fixture change types are fetched immediately, bypassing
+	// throttle. Default are start time, cancellation and format changes.
+	Urgent []uof.FixtureChangeType
+	// RefreshBefore fetches fixture again that long before its start time
+	// and next live time. Zero disables refresh.
+	RefreshBefore time.Duration
+	// TournamentRefresh is interval of the periodic refresh of long running
+	// tournaments. Zero disables refresh.
+	TournamentRefresh time.Duration
+}
+
+// FixturePolicies configures fixture fetching for each producer. Default
+// policy is used for producers without own policy.
+type FixturePolicies struct {
+	Default   FixturePolicy
+	Producers map[uof.Producer]FixturePolicy
+}
+
+func (p FixturePolicy) withDefaults() FixturePolicy {
+	if p.Throttle <= 0 {
+		p.Throttle = time.Minute
+	}
+	if p.Urgent == nil {
+		p.Urgent = []uof.FixtureChangeType{
+			uof.FixtureChangeTypeTime,
+			uof.FixtureChangeTypeCancelled,
+			uof.FixtureChangeTypeFromat,
+		}
+	}
+	return p
+}
+
+// urgent reports whether message requires immediate fixture fetch
+func (p FixturePolicy) urgent(m *uof.Message) bool {
+	if m.FixtureChange == nil || m.FixtureChange.ChangeType == nil {
+		return false
+	}
+	for _, ct := range p.Urgent {
+		if ct == *m.FixtureChange.ChangeType {
+			return true
+		}
+	}
+	return false
+}
+
+// nextRefresh returns time of the next scheduled fetch after the fixture is
+// fetched at now, zero if not needed
+func (p FixturePolicy) nextRefresh(eventURN uof.URN, start, nextLive, now time.Time) time.Time {
+	if eventURN.IsTournament() {
+		if p.TournamentRefresh <= 0 {
+			return time.Time{}
+		}
+		return now.Add(p.TournamentRefresh)
+	}
+	if p.RefreshBefore <= 0 {
+		return time.Time{}
+	}
+	var at time.Time
+	for _, t := range []time.Time{start, nextLive} {
+		if t.IsZero() {
+			continue
+		}
+		r := t.Add(-p.RefreshBefore)
+		if r.After(now) && (at.IsZero() || r.Before(at)) {
+			at = r
+		}
+	}
+	return at
+}
+
+func (ps FixturePolicies) policy(producer uof.Producer) FixturePolicy {
+	if p, ok := ps.Producers[producer]; ok {
+		return p.withDefaults()
+	}
+	return ps.Default.withDefaults()
+}
+
+// maxThrottle of all policies
+func (ps FixturePolicies) maxThrottle() time.Duration {
+	max := ps.Default.withDefaults().Throttle
+	for _, p := range ps.Producers {
+		if t := p.withDefaults().Throttle; t > max {
+			max = t
+		}
+	}
+	return max
+}
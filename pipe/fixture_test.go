@@ -1,6 +1,9 @@
 package pipe
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -17,6 +20,8 @@ type fixtureAPIMock struct {
 }
 
 func (m *fixtureAPIMock) Fixture(lang uof.Lang, eventURN uof.URN) (*uof.Fixture, error) {
+	m.Lock()
+	defer m.Unlock()
 	m.eventURN = eventURN
 	return &uof.Fixture{}, nil
 }
@@ -27,7 +32,9 @@ func (m *fixtureAPIMock) Summary(lang uof.Lang, eventURN uof.URN) (*uof.Summary,
 	return nil, nil
 }
 func (m *fixtureAPIMock) Fixtures(lang uof.Lang, to time.Time) (<-chan uof.Fixture, <-chan error) {
+	m.Lock()
 	m.preloadTo = to
+	m.Unlock()
 	out := make(chan uof.Fixture)
 	errc := make(chan error)
 	go func() {
@@ -71,3 +78,293 @@ func fixtureChangeMsg(t *testing.T) *uof.Message {
 	assert.NoError(t, err)
 	return m
 }
+
+type fixtureCacheAPIMock struct {
+	fixtures    []uof.Fixture
+	live        []uof.Fixture
+	changes     []uof.EventChange
+	preloads    int
+	fetched     []uof.URN
+	schedules   int
+	scheduleErr error
+	sync.Mutex
+}
+
+func (m *fixtureCacheAPIMock) Fixture(lang uof.Lang, eventURN uof.URN) (*uof.Fixture, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.fetched = append(m.fetched, eventURN)
+	for _, x := range m.fixtures {
+		if x.URN == eventURN {
+			return &x, nil
+		}
+	}
+	return &uof.Fixture{URN: eventURN}, nil
+}
+func (m *fixtureCacheAPIMock) Tournament(lang uof.Lang, eventURN uof.URN) (*uof.FixtureTournament, error) {
+	return nil, nil
+}
+func (m *fixtureCacheAPIMock) Summary(lang uof.Lang, eventURN uof.URN) (*uof.Summary, error) {
+	return nil, nil
+}
+func (m *fixtureCacheAPIMock) Fixtures(lang uof.Lang, to time.Time) (<-chan uof.Fixture, <-chan error) {
+	m.Lock()
+	m.preloads++
+	m.Unlock()
+	out := make(chan uof.Fixture)
+	errc := make(chan error)
+	go func() {
+		for _, x := range m.fixtures {
+			out <- x
+		}
+		close(out)
+		close(errc)
+	}()
+	return out, errc
+}
+func (m *fixtureCacheAPIMock) FixtureChanges(lang uof.Lang, after time.Time) ([]uof.EventChange, error) {
+	return m.changes, nil
+}
+func (m *fixtureCacheAPIMock) LiveSchedule(lang uof.Lang) ([]uof.Fixture, error) {
+	return m.live, nil
+}
+func (m *fixtureCacheAPIMock) Schedule(lang uof.Lang, date time.Time) ([]uof.Fixture, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.schedules++
+	if m.scheduleErr != nil {
+		return nil, m.scheduleErr
+	}
+	var xs []uof.Fixture
+	for _, x := range m.fixtures {
+		if x.Scheduled.Truncate(24 * time.Hour).Equal(date.Truncate(24 * time.Hour)) {
+			xs = append(xs, x)
+		}
+	}
+	return xs, nil
+}
+
+func runPreload(t *testing.T, a fixtureAPI, cfg PreloadConfig) ([]*uof.Message, []error) {
+	f := FixturePreload(a, []uof.Lang{uof.LangEN}, cfg)
+	in := make(chan *uof.Message)
+	out, errc := f(in)
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		for err := range errc {
+			errs = append(errs, err)
+		}
+		close(done)
+	}()
+	close(in)
+	var ms []*uof.Message
+	for m := range out {
+		ms = append(ms, m)
+	}
+	<-done
+	return ms, errs
+}
+
+func TestFixturePreloadCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixture_cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	a := &fixtureCacheAPIMock{
+		fixtures: []uof.Fixture{
+			{URN: "sr:match:1", Scheduled: now.Add(time.Hour), Sport: uof.Sport{ID: 1}},
+			{URN: "sr:match:2", Scheduled: now.Add(2 * time.Hour), Sport: uof.Sport{ID: 2}},
+			{URN: "sr:match:3", Scheduled: now.Add(48 * time.Hour), Sport: uof.Sport{ID: 1}},
+			{URN: "sr:match:4", Scheduled: now.Add(-time.Hour), Sport: uof.Sport{ID: 1}},
+		},
+	}
+	cfg := PreloadConfig{
+		From:     now.Add(-time.Minute),
+		To:       now.Add(24 * time.Hour),
+		Sports:   []int{1},
+		CacheDir: dir,
+	}
+
+	// first run, full preload by date from the start of the window
+	ms, errs := runPreload(t, a, cfg)
+	assert.Len(t, errs, 0)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, uof.URN("sr:match:1"), ms[0].Fixture.URN)
+	assert.Equal(t, 0, a.preloads)
+	assert.True(t, a.schedules > 0)
+	c := newFixtureCache(dir)
+	xs, err := c.all(uof.LangEN)
+	assert.NoError(t, err)
+	assert.Len(t, xs, 2)
+
+	// restart, only changed fixture is fetched
+	a.fixtures[0].Name = "changed"
+	a.changes = []uof.EventChange{{EventURN: "sr:match:1"}}
+	cfg.To = cfg.To.Add(time.Hour)
+	a.schedules = 0
+	ms, errs = runPreload(t, a, cfg)
+	assert.Len(t, errs, 0)
+	assert.Equal(t, 0, a.preloads)
+	assert.Equal(t, []uof.URN{"sr:match:1"}, a.fetched)
+	assert.True(t, a.schedules > 0)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, "changed", ms[0].Fixture.Name)
+	x, ok := c.get(uof.LangEN, "sr:match:1")
+	assert.True(t, ok)
+	assert.Equal(t, "changed", x.Name)
+
+	// schedule fails after cached fixtures are sent, they are not sent again
+	a.changes = nil
+	a.scheduleErr = uof.Notice("schedule", errors.New("timeout"))
+	cfg.To = cfg.To.Add(48 * time.Hour)
+	ms, errs = runPreload(t, a, cfg)
+	assert.Len(t, errs, 1)
+	assert.Len(t, ms, 1)
+	assert.Equal(t, 0, a.preloads)
+}
+
+func TestFixturePreloadLive(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	yesterday := uof.Fixture{URN: "sr:match:4", Scheduled: today.Add(-time.Hour)}
+	midnight := uof.Fixture{URN: "sr:match:5", Scheduled: today}
+	a := &fixtureCacheAPIMock{
+		fixtures: []uof.Fixture{midnight, {URN: "sr:match:1", Scheduled: today.Add(time.Hour)}},
+		live:     []uof.Fixture{yesterday, midnight},
+	}
+	ms, errs := runPreload(t, a, PreloadConfig{To: today.Add(2 * time.Hour)})
+	assert.Len(t, errs, 0)
+	var urns []uof.URN
+	for _, m := range ms {
+		urns = append(urns, m.Fixture.URN)
+	}
+	// live events started before today are preloaded, each fixture once
+	assert.Equal(t, []uof.URN{"sr:match:4", "sr:match:5", "sr:match:1"}, urns)
+	assert.Equal(t, 0, a.preloads)
+}
+
+func TestFixturePolicyThrottle(t *testing.T) {
+	a := &fixtureCacheAPIMock{}
+	f := FixtureStage(a, []uof.Lang{uof.LangEN}, FixtureConfig{})
+	in := make(chan *uof.Message)
+	out, _ := f(in)
+	done := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(done)
+	}()
+
+	send := func(changeType string) {
+		buf := []byte(`<fixture_change event_id="sr:match:1234" product="3"` + changeType + `/>`)
+		m, err := uof.NewQueueMessage("hi.pre.-.fixture_change.1.sr:match.1234.-", buf)
+		assert.NoError(t, err)
+		in <- m
+	}
+	send("")
+	send("")                 // throttled
+	send(` change_type="5"`) // coverage change is throttled
+	send(` change_type="2"`) // start time change is urgent
+	send(` change_type="3"`) // so is cancellation
+	close(in)
+	<-done
+
+	a.Lock()
+	defer a.Unlock()
+	assert.Len(t, a.fetched, 3)
+}
+
+func TestFixtureThrottleTrailingFetch(t *testing.T) {
+	a := &fixtureCacheAPIMock{}
+	f := FixtureStage(a, []uof.Lang{uof.LangEN}, FixtureConfig{
+		Policies: FixturePolicies{Default: FixturePolicy{Throttle: 20 * time.Millisecond}},
+	})
+	in := make(chan *uof.Message)
+	out, _ := f(in)
+	done := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(done)
+	}()
+	fetched := func() int {
+		a.Lock()
+		defer a.Unlock()
+		return len(a.fetched)
+	}
+
+	for i := 0; i < 3; i++ {
+		buf := []byte(`<fixture_change event_id="sr:match:1234" product="3" change_type="5"/>`)
+		m, err := uof.NewQueueMessage("hi.pre.-.fixture_change.1.sr:match.1234.-", buf)
+		assert.NoError(t, err)
+		in <- m
+	}
+	// throttled changes are fetched once after throttle interval
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, fetched())
+	close(in)
+	<-done
+}
+
+func TestFixturePolicyNextRefresh(t *testing.T) {
+	now := time.Now()
+	match := uof.URN("sr:match:1")
+	p := FixturePolicy{}.withDefaults()
+	assert.Equal(t, time.Minute, p.Throttle)
+	assert.True(t, p.nextRefresh(match, now.Add(time.Hour), time.Time{}, now).IsZero())
+
+	p.RefreshBefore = 10 * time.Minute
+	assert.Equal(t, now.Add(50*time.Minute), p.nextRefresh(match, now.Add(time.Hour), time.Time{}, now))
+	assert.Equal(t, now.Add(20*time.Minute), p.nextRefresh(match, now.Add(time.Hour), now.Add(30*time.Minute), now))
+	// already within refresh window
+	assert.True(t, p.nextRefresh(match, now.Add(5*time.Minute), time.Time{}, now).IsZero())
+
+	tournament := uof.URN("sr:season:1")
+	assert.True(t, p.nextRefresh(tournament, time.Time{}, time.Time{}, now).IsZero())
+	p.TournamentRefresh = time.Hour
+	assert.Equal(t, now.Add(time.Hour), p.nextRefresh(tournament, time.Time{}, time.Time{}, now))
+
+	ps := FixturePolicies{
+		Default:   FixturePolicy{Throttle: time.Second},
+		Producers: map[uof.Producer]FixturePolicy{uof.ProducerLiveOdds: {Throttle: time.Hour}},
+	}
+	assert.Equal(t, time.Second, ps.policy(uof.ProducerPrematch).Throttle)
+	assert.Equal(t, time.Hour, ps.policy(uof.ProducerLiveOdds).Throttle)
+	assert.Equal(t, time.Hour, ps.maxThrottle())
+}
+
+func TestFixtureRefreshSchedule(t *testing.T) {
+	start := time.Now().Add(2 * time.Hour)
+	a := &fixtureCacheAPIMock{fixtures: []uof.Fixture{
+		{URN: "sr:match:1", StartTime: start},
+		{URN: "sr:match:2", StartTime: start, Status: "closed"},
+	}}
+	f := newFixture(a, []uof.Lang{uof.LangEN}, FixtureConfig{
+		Policies: FixturePolicies{Default: FixturePolicy{RefreshBefore: time.Hour}},
+	})
+	f.errc, f.out = make(chan error, 8), make(chan *uof.Message, 8)
+	timers := func() int {
+		f.Lock()
+		defer f.Unlock()
+		return len(f.timers)
+	}
+
+	f.getFixture("sr:match:1", 0, false, uof.ProducerPrematch)
+	f.getFixture("sr:match:2", 0, false, uof.ProducerPrematch)
+	f.subProcs.Wait()
+	// finished event is not refreshed
+	assert.Equal(t, 1, timers())
+
+	m := statusMsg(t, 4, 100) // closed sr:match:1
+	assert.Equal(t, uof.URN("sr:match:1"), f.finished(m))
+	f.unschedule(f.finished(m))
+	assert.Equal(t, 0, timers())
+
+	// over the limit refresh is not scheduled
+	for i := 0; i < maxFixtureRefreshes; i++ {
+		f.timers[uof.NewEventURN(i+10)] = time.NewTimer(time.Hour)
+	}
+	f.schedule("sr:match:1", start, time.Time{}, uof.ProducerPrematch)
+	assert.Equal(t, maxFixtureRefreshes, timers())
+	f.stop()
+}
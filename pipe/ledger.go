@@ -0,0 +1,327 @@
+package pipe
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+type ledgerKey struct {
+	eventID  int
+	marketID int
+	lineID   int
+}
+
+type ledgerLine struct {
+	eventURN   uof.URN
+	specifiers map[string]string
+	certainty  *int8
+	outcomes   []uof.BetSettlementOutcome
+	live       []uof.BetSettlementOutcome // last live (certainty 1) settlement, until confirmed
+	cancels    []uof.CancelWindow
+}
+
+// CertaintyPolicy decides how live (certainty 1) and confirmed (certainty 2)
+// bet settlements change effective settlement.
+type CertaintyPolicy int8
+
+const (
+	// CertaintyReemit applies live settlement and emits change again when it is
+	// confirmed, even if outcomes are the same.
+	CertaintyReemit CertaintyPolicy = iota
+	// CertaintyTag applies live settlement, confirmation with the same outcomes
+	// only updates certainty without emitting change.
+	CertaintyTag
+	// CertaintyHold does not apply live settlements, line is settled when
+	// confirmed settlement is received.
+	CertaintyHold
+)
+
+// DefaultLedgerRetention is used when LedgerConfig.Retention is not set.
+const DefaultLedgerRetention = 7 * 24 * time.Hour
+
+// LedgerConfig configures settlement ledger.
+type LedgerConfig struct {
+	Certainty CertaintyPolicy
+	// Retention is how long lines of the event without settlement messages
+	// are kept.
+	Retention time.Duration
+}
+
+// SettlementLedger maintains effective settlement of each market line by
+// applying bet settlement, bet cancel and their rollbacks.
+//
+// Bet settlement replaces previous settlement of the line, except that live
+// (certainty 1) settlement does not replace confirmed (certainty 2) one.
+// Rollback bet settlement removes line settlement. Each bet cancel adds time
+// window to the line, rollback bet cancel removes window with the same start
+// and end time.
+// When confirmed settlement has different outcomes than the earlier live one,
+// change has Discrepancy set to the live outcomes.
+// Lines of the event are forgotten when there are no settlement messages for
+// the event in the retention.
+type SettlementLedger struct {
+	cfg       LedgerConfig
+	lines     map[ledgerKey]*ledgerLine
+	events    map[int]time.Time // last settlement message of the event
+	cleanedAt time.Time
+	sync.Mutex
+}
+
+// NewSettlementLedger creates empty ledger.
+func NewSettlementLedger(cfg LedgerConfig) *SettlementLedger {
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultLedgerRetention
+	}
+	return &SettlementLedger{
+		cfg:    cfg,
+		lines:  make(map[ledgerKey]*ledgerLine),
+		events: make(map[int]time.Time),
+	}
+}
+
+// Stage of the pipeline which applies messages to the ledger. After each bet
+// settlement, bet cancel or rollback message, settlement change message is
+// sent for each market line with changed effective settlement. Settlement
+// discrepancy message follows the change with discrepancy.
+func (l *SettlementLedger) Stage() InnerStage {
+	return Stage(func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+		for m := range in {
+			changes := l.Apply(m)
+			out <- m
+			for _, c := range changes {
+				out <- uof.NewSettlementChangeMessage(c)
+				if c.Discrepancy != nil {
+					out <- uof.NewSettlementDiscrepancyMessage(c)
+				}
+			}
+		}
+	})
+}
+
+// Apply message to the ledger. Returns effective settlement of the changed
+// market lines.
+func (l *SettlementLedger) Apply(m *uof.Message) []uof.SettlementChange {
+	return l.apply(m, time.Now())
+}
+
+func (l *SettlementLedger) apply(m *uof.Message, now time.Time) []uof.SettlementChange {
+	l.Lock()
+	defer l.Unlock()
+	l.cleanup(now)
+	switch {
+	case m.BetSettlement != nil:
+		l.events[m.BetSettlement.EventID] = now
+		return l.settle(m.BetSettlement)
+	case m.RollbackBetSettlement != nil:
+		l.events[m.RollbackBetSettlement.EventID] = now
+		return l.rollbackSettlement(m.RollbackBetSettlement)
+	case m.BetCancel != nil:
+		l.events[m.BetCancel.EventID] = now
+		return l.cancel(m.BetCancel)
+	case m.RollbackBetCancel != nil:
+		l.events[m.RollbackBetCancel.EventID] = now
+		return l.rollbackCancel(m.RollbackBetCancel)
+	}
+	return nil
+}
+
+// Line returns effective settlement of the market line.
+func (l *SettlementLedger) Line(eventID, marketID, lineID int) (uof.SettlementChange, bool) {
+	l.Lock()
+	defer l.Unlock()
+	k := ledgerKey{eventID: eventID, marketID: marketID, lineID: lineID}
+	ln, ok := l.lines[k]
+	if !ok {
+		return uof.SettlementChange{}, false
+	}
+	return ln.state(k), true
+}
+
+// Forget removes all lines of the event from the ledger.
+func (l *SettlementLedger) Forget(eventID int) {
+	l.Lock()
+	defer l.Unlock()
+	l.forget(eventID)
+}
+
+func (l *SettlementLedger) forget(eventID int) {
+	delete(l.events, eventID)
+	for k := range l.lines {
+		if k.eventID == eventID {
+			delete(l.lines, k)
+		}
+	}
+}
+
+// cleanup forgets events without settlement messages in the retention, at
+// most once an hour
+func (l *SettlementLedger) cleanup(now time.Time) {
+	if now.Sub(l.cleanedAt) < time.Hour {
+		return
+	}
+	l.cleanedAt = now
+	expired := make(map[int]bool)
+	for id, seen := range l.events {
+		if now.Sub(seen) > l.cfg.Retention {
+			expired[id] = true
+			delete(l.events, id)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	for k := range l.lines {
+		if expired[k.eventID] {
+			delete(l.lines, k)
+		}
+	}
+}
+
+func (l *SettlementLedger) line(eventID int, eventURN uof.URN, marketID, lineID int, specifiers map[string]string) (ledgerKey, *ledgerLine) {
+	k := ledgerKey{eventID: eventID, marketID: marketID, lineID: lineID}
+	ln, ok := l.lines[k]
+	if !ok {
+		ln = &ledgerLine{eventURN: eventURN, specifiers: specifiers}
+		l.lines[k] = ln
+	}
+	return k, ln
+}
+
+func (l *SettlementLedger) settle(bs *uof.BetSettlement) []uof.SettlementChange {
+	var changes []uof.SettlementChange
+	live := isLive(bs.Certainty)
+	for _, mk := range bs.Markets {
+		k, ln := l.line(bs.EventID, bs.EventURN, mk.ID, mk.LineID, mk.Specifiers)
+		var discrepancy []uof.BetSettlementOutcome
+		if live {
+			if isConfirmed(ln.certainty) {
+				continue // live settlement does not replace confirmed
+			}
+			ln.live = mk.Outcomes
+			if l.cfg.Certainty == CertaintyHold {
+				continue
+			}
+		} else {
+			if ln.live != nil && !reflect.DeepEqual(ln.live, mk.Outcomes) {
+				discrepancy = ln.live
+			}
+			ln.live = nil
+		}
+		if reflect.DeepEqual(ln.outcomes, mk.Outcomes) &&
+			(reflect.DeepEqual(ln.certainty, bs.Certainty) || l.cfg.Certainty == CertaintyTag) {
+			ln.certainty = bs.Certainty
+			continue
+		}
+		ln.outcomes = mk.Outcomes
+		ln.certainty = bs.Certainty
+		c := ln.change(k, bs.Producer, bs.Timestamp, uof.SettlementChangeSettled)
+		c.Discrepancy = discrepancy
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func isLive(certainty *int8) bool {
+	return certainty != nil && *certainty == 1
+}
+
+func isConfirmed(certainty *int8) bool {
+	return certainty != nil && *certainty == 2
+}
+
+func (l *SettlementLedger) rollbackSettlement(rb *uof.RollbackBetSettlement) []uof.SettlementChange {
+	var changes []uof.SettlementChange
+	for _, mk := range rb.Markets {
+		k := ledgerKey{eventID: rb.EventID, marketID: mk.ID, lineID: mk.LineID}
+		ln, ok := l.lines[k]
+		if !ok {
+			continue
+		}
+		ln.live = nil
+		if len(ln.outcomes) == 0 {
+			continue
+		}
+		ln.outcomes = nil
+		ln.certainty = nil
+		changes = append(changes, ln.change(k, rb.Producer, rb.Timestamp, uof.SettlementChangeRollback))
+	}
+	return changes
+}
+
+func (l *SettlementLedger) cancel(bc *uof.BetCancel) []uof.SettlementChange {
+	var changes []uof.SettlementChange
+	for _, mk := range bc.Markets {
+		k, ln := l.line(bc.EventID, bc.EventURN, mk.ID, mk.LineID, mk.Specifiers)
+		w := uof.CancelWindow{
+			StartTime:    bc.StartTime,
+			EndTime:      bc.EndTime,
+			VoidReason:   mk.VoidReason,
+			SupercededBy: bc.SupercededBy,
+		}
+		if ln.findCancel(bc.StartTime, bc.EndTime) >= 0 {
+			continue // already applied
+		}
+		ln.cancels = append(ln.cancels, w)
+		changes = append(changes, ln.change(k, bc.Producer, bc.Timestamp, uof.SettlementChangeCancel))
+	}
+	return changes
+}
+
+func (l *SettlementLedger) rollbackCancel(rb *uof.RollbackBetCancel) []uof.SettlementChange {
+	var changes []uof.SettlementChange
+	for _, mk := range rb.Markets {
+		k := ledgerKey{eventID: rb.EventID, marketID: mk.ID, lineID: mk.LineID}
+		ln, ok := l.lines[k]
+		if !ok {
+			continue
+		}
+		i := ln.findCancel(rb.StartTime, rb.EndTime)
+		if i < 0 {
+			continue
+		}
+		ln.cancels = append(ln.cancels[:i], ln.cancels[i+1:]...)
+		changes = append(changes, ln.change(k, rb.Producer, rb.Timestamp, uof.SettlementChangeCancelRollback))
+	}
+	return changes
+}
+
+// findCancel returns index of the cancel window with the same time range
+func (ln *ledgerLine) findCancel(start, end *int) int {
+	for i, w := range ln.cancels {
+		if equalInt(w.StartTime, start) && equalInt(w.EndTime, end) {
+			return i
+		}
+	}
+	return -1
+}
+
+func equalInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (ln *ledgerLine) state(k ledgerKey) uof.SettlementChange {
+	return uof.SettlementChange{
+		EventID:       k.eventID,
+		EventURN:      ln.eventURN,
+		MarketID:      k.marketID,
+		LineID:        k.lineID,
+		Specifiers:    ln.specifiers,
+		Certainty:     ln.certainty,
+		Outcomes:      append([]uof.BetSettlementOutcome(nil), ln.outcomes...),
+		Cancellations: append([]uof.CancelWindow(nil), ln.cancels...),
+	}
+}
+
+func (ln *ledgerLine) change(k ledgerKey, producer uof.Producer, ts int, reason uof.SettlementChangeReason) uof.SettlementChange {
+	c := ln.state(k)
+	c.Producer = producer
+	c.Timestamp = ts
+	c.Reason = reason
+	return c
+}
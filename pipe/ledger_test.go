@@ -0,0 +1,166 @@
+package pipe
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func ledgerMsg(t *testing.T, typ, buf string) *uof.Message {
+	m, err := uof.NewQueueMessage("lo.-.live."+typ+".1.sr:match.1.-", []byte(buf))
+	assert.NoError(t, err)
+	return m
+}
+
+func TestSettlementLedger(t *testing.T) {
+	l := NewSettlementLedger(LedgerConfig{})
+
+	// live settlement
+	cs := l.Apply(ledgerMsg(t, "bet_settlement", `<bet_settlement event_id="sr:match:1" product="1" timestamp="1" certainty="1"><outcomes>
+		<market id="1"><outcome id="1" result="1"/><outcome id="2" result="0"/></market>
+		<market id="18" specifiers="total=2.5"><outcome id="12" result="1" void_factor="0.5"/></market>
+	</outcomes></bet_settlement>`))
+	assert.Len(t, cs, 2)
+	assert.Equal(t, uof.SettlementChangeSettled, cs[0].Reason)
+	assert.Equal(t, uof.OutcomeResultWin, cs[0].Outcomes[0].Result)
+	assert.Equal(t, uof.OutcomeResultHalfWin, cs[1].Outcomes[0].Result)
+	assert.Equal(t, 0.5, cs[1].Outcomes[0].VoidFactor)
+	lineID := cs[1].LineID
+
+	// same confirmed settlement changes only certainty
+	confirmed := `<bet_settlement event_id="sr:match:1" product="1" timestamp="2" certainty="2"><outcomes>
+		<market id="1"><outcome id="1" result="1"/><outcome id="2" result="0"/></market>
+	</outcomes></bet_settlement>`
+	cs = l.Apply(ledgerMsg(t, "bet_settlement", confirmed))
+	assert.Len(t, cs, 1)
+	assert.Equal(t, int8(2), *cs[0].Certainty)
+	assert.Len(t, l.Apply(ledgerMsg(t, "bet_settlement", confirmed)), 0)
+
+	// live settlement does not replace confirmed
+	cs = l.Apply(ledgerMsg(t, "bet_settlement", `<bet_settlement event_id="sr:match:1" product="1" timestamp="3" certainty="1"><outcomes>
+		<market id="1"><outcome id="1" result="0"/><outcome id="2" result="1"/></market>
+	</outcomes></bet_settlement>`))
+	assert.Len(t, cs, 0)
+
+	// rollback
+	cs = l.Apply(ledgerMsg(t, "rollback_bet_settlement", `<rollback_bet_settlement event_id="sr:match:1" product="1" timestamp="4">
+		<market id="1"/><market id="2"/></rollback_bet_settlement>`))
+	assert.Len(t, cs, 1)
+	assert.Equal(t, uof.SettlementChangeRollback, cs[0].Reason)
+	assert.False(t, cs[0].Settled())
+
+	// cancel windows
+	cancel := `<bet_cancel event_id="sr:match:1" product="1" timestamp="5" start_time="100" end_time="200">
+		<market id="18" specifiers="total=2.5" void_reason="12"/></bet_cancel>`
+	cs = l.Apply(ledgerMsg(t, "bet_cancel", cancel))
+	assert.Len(t, cs, 1)
+	assert.Equal(t, uof.SettlementChangeCancel, cs[0].Reason)
+	assert.True(t, cs[0].Settled())
+	assert.Len(t, cs[0].Cancellations, 1)
+	assert.True(t, cs[0].Cancellations[0].Cancels(150))
+	assert.False(t, cs[0].Cancellations[0].Cancels(250))
+	assert.Len(t, l.Apply(ledgerMsg(t, "bet_cancel", cancel)), 0)
+
+	cs = l.Apply(ledgerMsg(t, "bet_cancel", `<bet_cancel event_id="sr:match:1" product="1" timestamp="6" end_time="50">
+		<market id="18" specifiers="total=2.5"/></bet_cancel>`))
+	assert.Len(t, cs[0].Cancellations, 2)
+
+	cs = l.Apply(ledgerMsg(t, "rollback_bet_cancel", `<rollback_bet_cancel event_id="sr:match:1" product="1" timestamp="7" start_time="100" end_time="200">
+		<market id="18" specifiers="total=2.5"/></rollback_bet_cancel>`))
+	assert.Len(t, cs, 1)
+	assert.Equal(t, uof.SettlementChangeCancelRollback, cs[0].Reason)
+	assert.Len(t, cs[0].Cancellations, 1)
+	assert.Equal(t, 50, *cs[0].Cancellations[0].EndTime)
+
+	s, ok := l.Line(1, 18, lineID)
+	assert.True(t, ok)
+	assert.True(t, s.Settled())
+	assert.Len(t, s.Cancellations, 1)
+
+	l.Forget(1)
+	_, ok = l.Line(1, 18, lineID)
+	assert.False(t, ok)
+}
+
+func TestSettlementLedgerStage(t *testing.T) {
+	in := make(chan *uof.Message, 1)
+	in <- ledgerMsg(t, "bet_settlement", `<bet_settlement event_id="sr:match:1" product="1" timestamp="1" certainty="1"><outcomes>
+		<market id="1"><outcome id="1" result="1"/></market></outcomes></bet_settlement>`)
+	close(in)
+	out, _ := NewSettlementLedger(LedgerConfig{}).Stage()(in)
+	assert.Equal(t, uof.MessageTypeBetSettlement, (<-out).Type)
+	m := <-out
+	assert.Equal(t, uof.MessageTypeSettlementChange, m.Type)
+	assert.Equal(t, uof.MessageKindEvent, m.Type.Kind())
+	assert.Equal(t, uof.MessageScopeLive, m.Scope)
+	assert.Equal(t, 1, m.EventID)
+	assert.Equal(t, 1, m.SettlementChange.MarketID)
+}
+
+func TestSettlementLedgerCertainty(t *testing.T) {
+	settlement := func(certainty, result int) *uof.Message {
+		return ledgerMsg(t, "bet_settlement", fmt.Sprintf(`<bet_settlement event_id="sr:match:1" product="1" timestamp="1" certainty="%d"><outcomes>
+			<market id="1"><outcome id="1" result="%d"/></market></outcomes></bet_settlement>`, certainty, result))
+	}
+
+	l := NewSettlementLedger(LedgerConfig{Certainty: CertaintyHold})
+	assert.Len(t, l.Apply(settlement(1, 1)), 0)
+	_, ok := l.Line(1, 1, 0)
+	assert.True(t, ok)
+	cs := l.Apply(settlement(2, 1))
+	assert.Len(t, cs, 1)
+	assert.Nil(t, cs[0].Discrepancy)
+
+	l = NewSettlementLedger(LedgerConfig{Certainty: CertaintyTag})
+	assert.Len(t, l.Apply(settlement(1, 1)), 1)
+	assert.Len(t, l.Apply(settlement(2, 1)), 0)
+	s, _ := l.Line(1, 1, 0)
+	assert.Equal(t, int8(2), *s.Certainty)
+
+	l = NewSettlementLedger(LedgerConfig{Certainty: CertaintyReemit})
+	assert.Len(t, l.Apply(settlement(1, 1)), 1)
+	assert.Len(t, l.Apply(settlement(2, 1)), 1)
+
+	// discrepancy
+	in := make(chan *uof.Message, 2)
+	in <- settlement(1, 1)
+	in <- settlement(2, 0)
+	close(in)
+	out, _ := NewSettlementLedger(LedgerConfig{Certainty: CertaintyHold}).Stage()(in)
+	var types []uof.MessageType
+	var last *uof.Message
+	for m := range out {
+		types = append(types, m.Type)
+		last = m
+	}
+	assert.Equal(t, []uof.MessageType{
+		uof.MessageTypeBetSettlement,
+		uof.MessageTypeBetSettlement,
+		uof.MessageTypeSettlementChange,
+		uof.MessageTypeSettlementDiscrepancy,
+	}, types)
+	assert.Equal(t, uof.OutcomeResultWin, last.SettlementChange.Discrepancy[0].Result)
+	assert.Equal(t, uof.OutcomeResultLose, last.SettlementChange.Outcomes[0].Result)
+}
+
+func TestSettlementLedgerRetention(t *testing.T) {
+	settlement := func(eventID int) *uof.Message {
+		return ledgerMsg(t, "bet_settlement", fmt.Sprintf(`<bet_settlement event_id="sr:match:%d" product="1" timestamp="1" certainty="2"><outcomes>
+			<market id="1"><outcome id="1" result="1"/></market></outcomes></bet_settlement>`, eventID))
+	}
+	l := NewSettlementLedger(LedgerConfig{Retention: 48 * time.Hour})
+	now := time.Now()
+	l.apply(settlement(1), now)
+	l.apply(settlement(2), now.Add(24*time.Hour))
+
+	now = now.Add(49 * time.Hour)
+	l.apply(settlement(3), now)
+	_, ok := l.Line(1, 1, 0)
+	assert.False(t, ok)
+	_, ok = l.Line(2, 1, 0)
+	assert.True(t, ok)
+	assert.Len(t, l.events, 2)
+}
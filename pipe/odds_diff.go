@@ -0,0 +1,106 @@
+package pipe
+
+import (
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// oddsDiffRetention is how long state of the event without odds changes is
+// kept
+const oddsDiffRetention = 48 * time.Hour
+
+type lineKey struct {
+	marketID int
+	lineID   int
+}
+
+type oddsDiffEvent struct {
+	lines map[lineKey]uof.Market
+	seen  time.Time // of the last odds change
+}
+
+type oddsDiff struct {
+	events    map[eventKey]*oddsDiffEvent
+	cleanedAt time.Time
+}
+
+// OddsDiff keeps the last state of the markets for each event and producer
+// and sets OddsChange.Diff on every odds change message. Odds diff message
+// follows the odds change with changed markets. State of the event is
+// removed when the event is closed, cancelled or abandoned, or when there was
+// no odds change for two days.
+func OddsDiff() InnerStage {
+	d := &oddsDiff{events: make(map[eventKey]*oddsDiffEvent)}
+	return Stage(d.loop)
+}
+
+func (d *oddsDiff) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+	for m := range in {
+		if m.Type != uof.MessageTypeOddsChange || m.OddsChange == nil {
+			out <- m
+			continue
+		}
+		d.diff(messageEventKey(m), m.OddsChange, time.Now())
+		out <- m
+		if diff := m.OddsChange.Diff; len(diff.Markets) > 0 {
+			out <- m.NewOddsDiffMessage(*diff)
+		}
+	}
+}
+
+func (d *oddsDiff) diff(ek eventKey, oc *uof.OddsChange, now time.Time) {
+	d.cleanup(now)
+	e, ok := d.events[ek]
+	if !ok {
+		e = &oddsDiffEvent{lines: make(map[lineKey]uof.Market)}
+		d.events[ek] = e
+	}
+	e.seen = now
+	lines := e.lines
+	diff := &uof.OddsChangeDiff{}
+	for _, cur := range oc.Markets {
+		key := lineKey{marketID: cur.ID, lineID: cur.LineID}
+		prev, found := lines[key]
+		switch {
+		case !found:
+			if !cur.Removed() {
+				diff.Markets = append(diff.Markets, uof.MarketDiff{ID: cur.ID, LineID: cur.LineID, Added: true})
+			}
+		default:
+			if md, changed := uof.DiffMarket(prev, cur); changed {
+				diff.Markets = append(diff.Markets, md)
+			}
+		}
+		if cur.Removed() {
+			delete(lines, key)
+			continue
+		}
+		if len(cur.Outcomes) == 0 { // suspended market without outcomes
+			cur.Outcomes = prev.Outcomes
+		}
+		lines[key] = cur
+	}
+	oc.Diff = diff
+
+	if es := oc.EventStatus; es != nil {
+		switch es.Status {
+		case uof.EventStatusClosed, uof.EventStatusCancelled, uof.EventStatusAbandoned:
+			delete(d.events, ek)
+		}
+	}
+}
+
+// cleanup removes events without odds changes in the retention, at most once
+// an hour
+func (d *oddsDiff) cleanup(now time.Time) {
+	if now.Sub(d.cleanedAt) < time.Hour {
+		return
+	}
+	d.cleanedAt = now
+	for k, e := range d.events {
+		if now.Sub(e.seen) > oddsDiffRetention {
+			delete(d.events, k)
+		}
+	}
+}
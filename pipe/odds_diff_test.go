@@ -0,0 +1,101 @@
+package pipe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOddsDiff(t *testing.T) {
+	d := &oddsDiff{events: make(map[eventKey]*oddsDiffEvent)}
+	now := time.Now()
+	apply := func(m *uof.Message) {
+		d.diff(messageEventKey(m), m.OddsChange, now)
+	}
+
+	m := coalesceOddsChange(t, 1, 1, market(1, "1.5", 1), market(2, "2.0", 1))
+	apply(m)
+	diff := m.OddsChange.Diff
+	assert.Len(t, diff.Markets, 2)
+	assert.True(t, diff.Markets[0].Added)
+	assert.True(t, diff.Markets[1].Added)
+
+	m = coalesceOddsChange(t, 1, 1, market(1, "1.4", 1), market(2, "2.0", -1),
+		`<market id="3" favourite="1"><outcome id="1" odds="3.0" active="0"/></market>`)
+	apply(m)
+	diff = m.OddsChange.Diff
+	assert.Len(t, diff.Markets, 3)
+
+	md := diff.Markets[0]
+	assert.Equal(t, 1, md.ID)
+	assert.Nil(t, md.Status)
+	assert.Len(t, md.Outcomes, 1)
+	o := md.Outcomes[0]
+	assert.Equal(t, 1.5, o.OddsFrom)
+	assert.Equal(t, 1.4, o.OddsTo)
+	assert.InDelta(t, -0.1, o.Delta, 0.0001)
+	assert.Equal(t, uof.OddsDown, o.Direction)
+
+	md = diff.Markets[1]
+	assert.Equal(t, &uof.MarketStatusChange{From: uof.MarketStatusActive, To: uof.MarketStatusSuspended}, md.Status)
+	assert.Len(t, md.Outcomes, 0)
+	assert.True(t, diff.Markets[2].Added)
+
+	m = coalesceOddsChange(t, 1, 1, market(2, "2.0", 0),
+		`<market id="3"><outcome id="1" odds="3.0" active="1"/></market>`)
+	apply(m)
+	diff = m.OddsChange.Diff
+	assert.Len(t, diff.Markets, 2)
+	assert.True(t, diff.Markets[0].Removed)
+	assert.Equal(t, &uof.BoolChange{From: true, To: false}, diff.Markets[1].Favourite)
+	assert.Equal(t, &uof.BoolChange{From: false, To: true}, diff.Markets[1].Outcomes[0].Active)
+	assert.Len(t, d.events[messageEventKey(m)].lines, 2)
+
+	// prematch producer odds are not compared with live
+	pm := coalesceOddsChange(t, 1, 1, market(2, "2.5", 1))
+	pm.Producer = uof.ProducerPrematch
+	apply(pm)
+	assert.True(t, pm.OddsChange.Diff.Markets[0].Added)
+	assert.Len(t, d.events, 2)
+	now = now.Add(oddsDiffRetention + time.Hour)
+	d.cleanup(now)
+	assert.Len(t, d.events, 0)
+
+	m = coalesceOddsChange(t, 1, 1, market(1, "1.4", 1))
+	apply(m)
+	assert.Len(t, d.events, 1)
+	m = coalesceOddsChange(t, 1, 4, market(1, "1.4", 1))
+	apply(m)
+	assert.Len(t, m.OddsChange.Diff.Markets, 0)
+	assert.Len(t, d.events, 0)
+}
+
+func TestOddsDiffStage(t *testing.T) {
+	in := make(chan *uof.Message, 3)
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.5", 1))
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.5", 1)) // no change
+	in <- coalesceOddsChange(t, 1, 1, market(1, "1.4", 1))
+	close(in)
+	out, _ := OddsDiff()(in)
+	var types []uof.MessageType
+	var last *uof.Message
+	for m := range out {
+		types = append(types, m.Type)
+		last = m
+	}
+	assert.Equal(t, []uof.MessageType{
+		uof.MessageTypeOddsChange, uof.MessageTypeOddsDiff,
+		uof.MessageTypeOddsChange,
+		uof.MessageTypeOddsChange, uof.MessageTypeOddsDiff,
+	}, types)
+	assert.Equal(t, 1, last.EventID)
+	assert.Equal(t, uof.MessageKindEvent, last.Type.Kind())
+
+	// diff message is kept when marshaled
+	var m uof.Message
+	assert.NoError(t, m.Unmarshal(last.Marshal()))
+	assert.Equal(t, uof.MessageTypeOddsDiff, m.Type)
+	assert.Equal(t, 1.5, m.OddsDiff.Markets[0].Outcomes[0].OddsFrom)
+}
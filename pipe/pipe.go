@@ -26,13 +26,13 @@ const ConcurentAPICallsLimit = 16
 // the sink or consumer.
 // Reference: https://blog.golang.org/pipelines
 
-type sourceStage func() (<-chan *uof.Message, <-chan error)
+type SourceStage func() (<-chan *uof.Message, <-chan error)
 type InnerStage func(<-chan *uof.Message) (<-chan *uof.Message, <-chan error)
 type ConsumerStage func(in <-chan *uof.Message) error
 type stageFunc func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error)
 type stageWithDrainFunc func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) *sync.WaitGroup
 
-func Build(source sourceStage, stages ...InnerStage) <-chan error {
+func Build(source SourceStage, stages ...InnerStage) <-chan error {
 	errors := make([]<-chan error, 0, len(stages)+2)
 	in, errc := source()
 	errors = append(errors, errc)
@@ -236,35 +236,84 @@ func Simple(each func(m *uof.Message) error) InnerStage {
 	}
 }
 
+// eventKey identifies event state of the producer. Live and prematch
+// producers send different state for the same event.
+type eventKey struct {
+	eventID  int
+	producer uof.Producer
+}
+
+func messageEventKey(m *uof.Message) eventKey {
+	return eventKey{eventID: m.EventID, producer: m.Producer}
+}
+
 type expireMap struct {
 	m        map[int]int
+	order    []expireEntry // in insert order, for cleanup and eviction
 	interval time.Duration
+	maxSize  int
+	// expired keys are removed on insert, at most once in the interval
+	cleanedAt time.Time
 	sync.Mutex
 }
 
+type expireEntry struct {
+	key int
+	ts  int
+}
+
 func newExpireMap(expireAfter time.Duration) *expireMap {
-	em := &expireMap{
+	return newBoundedExpireMap(expireAfter, 0)
+}
+
+// newBoundedExpireMap keeps at most maxSize keys, oldest are removed first.
+// Zero maxSize is unbounded.
+func newBoundedExpireMap(expireAfter time.Duration, maxSize int) *expireMap {
+	return &expireMap{
 		m:        make(map[int]int),
 		interval: expireAfter,
+		maxSize:  maxSize,
 	}
-	go func() {
-		time.Sleep(expireAfter * 2)
-		em.cleanup()
-	}()
-	return em
 }
 
 func (em *expireMap) cleanup() {
 	em.Lock()
 	defer em.Unlock()
+	em.removeExpired()
+}
 
-	for k, v := range em.m {
-		if em.expired(v) {
-			delete(em.m, k)
+// removeExpired keys from the start of the insert order, lock must be held
+func (em *expireMap) removeExpired() {
+	em.cleanedAt = time.Now()
+	for len(em.order) > 0 {
+		e := em.order[0]
+		if v, ok := em.m[e.key]; ok && v == e.ts {
+			if !em.expired(v) {
+				return
+			}
+			delete(em.m, e.key)
+		}
+		em.order = em.order[1:]
+	}
+}
+
+// evict oldest keys until size is in bounds
+func (em *expireMap) evict() {
+	for em.maxSize > 0 && len(em.m) > em.maxSize && len(em.order) > 0 {
+		e := em.order[0]
+		if v, ok := em.m[e.key]; ok && v == e.ts {
+			delete(em.m, e.key)
 		}
+		em.order = em.order[1:]
 	}
 }
 
+func (em *expireMap) size() int {
+	em.Lock()
+	defer em.Unlock()
+	return len(em.m)
+}
+
 func (em *expireMap) expired(v int) bool {
 	return v < em.checkpoint()
 }
@@ -287,7 +336,14 @@ func (em *expireMap) insert(key int) {
 	em.Lock()
 	defer em.Unlock()
 
-	em.m[key] = int(time.Now().UnixNano())
+	now := time.Now()
+	if now.Sub(em.cleanedAt) > em.interval {
+		em.removeExpired()
+	}
+	ts := int(now.UnixNano())
+	em.m[key] = ts
+	em.order = append(em.order, expireEntry{key: key, ts: ts})
+	em.evict()
 }
 
 func (em *expireMap) remove(key int) {
@@ -12,3 +12,29 @@ func TestExpireMap(t *testing.T) {
 	em.insert(1)
 	assert.True(t, em.fresh(1))
 }
+
+func TestBoundedExpireMap(t *testing.T) {
+	em := newBoundedExpireMap(time.Minute, 2)
+	em.insert(1)
+	em.insert(2)
+	em.insert(3)
+	assert.Equal(t, 2, em.size())
+	assert.False(t, em.fresh(1))
+	assert.True(t, em.fresh(2))
+	assert.True(t, em.fresh(3))
+
+	em.interval = 0
+	em.cleanup()
+	assert.Equal(t, 0, em.size())
+	assert.Len(t, em.order, 0)
+}
+
+func TestExpireMapCleanupOnInsert(t *testing.T) {
+	em := newExpireMap(time.Millisecond)
+	em.insert(1)
+	em.insert(2)
+	time.Sleep(2 * time.Millisecond)
+	em.insert(3)
+	assert.Equal(t, 1, em.size())
+	assert.Len(t, em.order, 1)
+}
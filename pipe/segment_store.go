@@ -0,0 +1,669 @@
+package pipe
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/minus5/go-uof-sdk"
+)
+
+// Segment log stores messages into append-only segment files instead of one
+// file per message (as FileStore does).
+//
+// Each record in the segment is 4 bytes big endian length followed by
+// Message.Marshal() bytes. Active segment is rotated when it reaches
+// MaxSegmentSize or MaxSegmentAge. Closed segments are optionally compressed
+// with the configured codec. Next to each segment is an index file with one
+// line per record: receivedAt, eventURN and offset of the record in the
+// (uncompressed) segment.
+//
+// Directory layout:
+//   00000001.log      closed segment
+//   00000001.idx      index of the segment
+//   00000002.log.gz   closed, compressed segment
+//   00000003.log      active segment
+
+const (
+	segmentExt = ".log"
+	indexExt   = ".idx"
+	tmpExt     = ".tmp"
+)
+
+// SegmentCodec compresses closed segments. GzipCodec and ZstdCodec are
+// provided, other compressions can be plugged in by implementing this
+// interface.
+type SegmentCodec interface {
+	Ext() string // file extension added to the compressed segment
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Ext() string { return ".gz" }
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// GzipCodec compresses closed segments with gzip.
+var GzipCodec SegmentCodec = gzipCodec{}
+
+type zstdCodec struct{}
+
+func (zstdCodec) Ext() string { return ".zst" }
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// ZstdCodec compresses closed segments with zstd.
+var ZstdCodec SegmentCodec = zstdCodec{}
+
+// SegmentLogConfig configures rotation, compression and retention of the
+// segment log. Zero values disable that feature.
+type SegmentLogConfig struct {
+	MaxSegmentSize int           // rotate when segment reaches size in bytes
+	MaxSegmentAge  time.Duration // rotate on first append after segment is older than this
+	Codec          SegmentCodec  // compress closed segments with codec
+	MaxSegments    int           // keep at most this number of segments
+	MaxAge         time.Duration // remove closed segments older than this
+}
+
+// SegmentQuery selects messages in the segment log scan. Zero values are not
+// limiting.
+type SegmentQuery struct {
+	From     int     // ReceivedAt >= From
+	To       int     // ReceivedAt < To
+	EventURN uof.URN // only messages of this event
+}
+
+func (q SegmentQuery) match(receivedAt int, eventURN uof.URN) bool {
+	if q.From > 0 && receivedAt < q.From {
+		return false
+	}
+	if q.To > 0 && receivedAt >= q.To {
+		return false
+	}
+	if q.EventURN != uof.NoURN && eventURN != q.EventURN {
+		return false
+	}
+	return true
+}
+
+// SegmentLog is append-only, rotating message log.
+type SegmentLog struct {
+	dir      string
+	cfg      SegmentLogConfig
+	seq      int // sequence of the active segment
+	seg      *os.File
+	segBuf   *bufio.Writer
+	idx      *os.File
+	idxBuf   *bufio.Writer
+	size     int
+	openedAt time.Time
+	jobs     chan segmentJob // closed segments for the background worker
+	done     chan struct{}   // closed when the worker exits
+	errs     []error         // background errors not yet reported
+	errMu    sync.Mutex
+	files    sync.Mutex // serializes compression, retention and scan
+	sync.Mutex
+}
+
+// segmentJob is compression and retention of one closed segment.
+type segmentJob struct {
+	seq    int // closed segment, zero for retention only
+	active int // active segment at the time of rotation
+}
+
+// OpenSegmentLog opens segment log in the dir. New active segment is started
+// after the existing ones. Incomplete record at the end of the previous active
+// segment (after crash) is truncated, then the segment is compressed and
+// counted in retention as any other closed segment.
+func OpenSegmentLog(dir string, cfg SegmentLogConfig) (*SegmentLog, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, uof.Notice("segment log open", err)
+	}
+	segs, err := segments(dir)
+	if err != nil {
+		return nil, uof.Notice("segment log open", err)
+	}
+	l := &SegmentLog{
+		dir:  dir,
+		cfg:  cfg,
+		jobs: make(chan segmentJob, 16),
+		done: make(chan struct{}),
+	}
+	tail := 0 // uncompressed last segment of the previous run
+	if len(segs) > 0 {
+		last := segs[len(segs)-1]
+		l.seq = last.seq
+		if strings.HasSuffix(last.name, segmentExt) {
+			tail = last.seq
+			if err := repairSegment(l.path(tail, segmentExt), l.path(tail, indexExt)); err != nil {
+				return nil, uof.Notice("segment log repair", err)
+			}
+		}
+	}
+	if err := l.open(); err != nil {
+		return nil, uof.Notice("segment log open", err)
+	}
+	go l.worker(l.jobs)
+	// previous active segment is closed now, compress it and apply retention
+	l.jobs <- segmentJob{seq: tail, active: l.seq}
+	return l, nil
+}
+
+// repairSegment truncates incomplete record at the end of the segment, left
+// after crash, and removes index lines pointing past the last whole record.
+func repairSegment(seg, idx string) error {
+	f, err := os.OpenFile(seg, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	valid := 0
+	var hdr [4]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return err
+		}
+		size := int(binary.BigEndian.Uint32(hdr[:]))
+		if _, err := io.CopyN(ioutil.Discard, r, int64(size)); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		valid += len(hdr) + size
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() > int64(valid) {
+		if err := f.Truncate(int64(valid)); err != nil {
+			return err
+		}
+	}
+
+	buf, err := ioutil.ReadFile(idx)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var keep []byte
+	for _, line := range strings.SplitAfter(string(buf), "\n") {
+		p := strings.Fields(line)
+		if len(p) != 3 || !strings.HasSuffix(line, "\n") {
+			continue
+		}
+		if offset, err := strconv.Atoi(p[2]); err != nil || offset >= valid {
+			continue
+		}
+		keep = append(keep, line...)
+	}
+	if len(keep) == len(buf) {
+		return nil
+	}
+	return ioutil.WriteFile(idx, keep, 0644)
+}
+
+// worker compresses closed segments and applies retention, one segment at a
+// time, in the order they are closed.
+func (l *SegmentLog) worker(jobs <-chan segmentJob) {
+	defer close(l.done)
+	for j := range jobs {
+		l.files.Lock()
+		if l.cfg.Codec != nil && j.seq > 0 {
+			if err := compressSegment(l.path(j.seq, segmentExt), l.cfg.Codec); err != nil {
+				l.fail(uof.Notice("segment log compress", err))
+			}
+		}
+		if err := l.retention(j.active); err != nil {
+			l.fail(uof.Notice("segment log retention", err))
+		}
+		l.files.Unlock()
+	}
+}
+
+func (l *SegmentLog) fail(err error) {
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	l.errs = append(l.errs, err)
+}
+
+// takeErrors returns and clears errors of the background worker.
+func (l *SegmentLog) takeErrors() []error {
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	errs := l.errs
+	l.errs = nil
+	return errs
+}
+
+func (l *SegmentLog) path(seq int, ext string) string {
+	return filepath.Join(l.dir, segmentName(seq)+ext)
+}
+
+func segmentName(seq int) string {
+	return fmt.Sprintf("%08d", seq)
+}
+
+// open starts new active segment
+func (l *SegmentLog) open() error {
+	l.seq++
+	seg, err := os.OpenFile(l.path(l.seq, segmentExt), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	idx, err := os.OpenFile(l.path(l.seq, indexExt), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		seg.Close()
+		return err
+	}
+	l.seg, l.segBuf = seg, bufio.NewWriter(seg)
+	l.idx, l.idxBuf = idx, bufio.NewWriter(idx)
+	l.size = 0
+	l.openedAt = time.Now()
+	return nil
+}
+
+// Append message to the active segment.
+func (l *SegmentLog) Append(m *uof.Message) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.rotationNeeded() {
+		if err := l.rotate(); err != nil {
+			return uof.Notice("segment log rotate", err)
+		}
+	}
+	buf := m.Marshal()
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(buf)))
+	if _, err := l.segBuf.Write(hdr[:]); err != nil {
+		return uof.Notice("segment log append", err)
+	}
+	if _, err := l.segBuf.Write(buf); err != nil {
+		return uof.Notice("segment log append", err)
+	}
+	if _, err := fmt.Fprintf(l.idxBuf, "%d %s %d\n", m.ReceivedAt, indexURN(m.EventURN), l.size); err != nil {
+		return uof.Notice("segment log index", err)
+	}
+	l.size += len(hdr) + len(buf)
+	return nil
+}
+
+func indexURN(u uof.URN) string {
+	if u == uof.NoURN {
+		return "-"
+	}
+	return string(u)
+}
+
+func (l *SegmentLog) rotationNeeded() bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.cfg.MaxSegmentSize > 0 && l.size >= l.cfg.MaxSegmentSize {
+		return true
+	}
+	if l.cfg.MaxSegmentAge > 0 && time.Since(l.openedAt) >= l.cfg.MaxSegmentAge {
+		return true
+	}
+	return false
+}
+
+// Flush buffered records to the disk.
+func (l *SegmentLog) Flush() error {
+	l.Lock()
+	defer l.Unlock()
+	return l.flush()
+}
+
+func (l *SegmentLog) flush() error {
+	if err := l.segBuf.Flush(); err != nil {
+		return err
+	}
+	return l.idxBuf.Flush()
+}
+
+func (l *SegmentLog) close() error {
+	if err := l.flush(); err != nil {
+		return err
+	}
+	if err := l.seg.Close(); err != nil {
+		return err
+	}
+	return l.idx.Close()
+}
+
+func (l *SegmentLog) rotate() error {
+	if err := l.close(); err != nil {
+		return err
+	}
+	closed := l.seq
+	if err := l.open(); err != nil {
+		return err
+	}
+	l.jobs <- segmentJob{seq: closed, active: l.seq}
+	return nil
+}
+
+// Close the active segment and wait for pending compressions. Active segment
+// is not compressed. Returns the first error of the background compression
+// or retention which is not already reported.
+func (l *SegmentLog) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.jobs == nil {
+		return nil
+	}
+	err := l.close()
+	close(l.jobs)
+	l.jobs = nil
+	<-l.done
+	if errs := l.takeErrors(); err == nil && len(errs) > 0 {
+		err = errs[0]
+	}
+	return err
+}
+
+// compressSegment replaces segment file with the compressed one
+func compressSegment(name string, codec SegmentCodec) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := name + codec.Ext() + tmpExt
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w, err := codec.NewWriter(dst)
+	if err == nil {
+		_, err = io.Copy(w, src)
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name+codec.Ext()); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// retention removes the oldest closed segments over the limits
+func (l *SegmentLog) retention(active int) error {
+	if l.cfg.MaxSegments <= 0 && l.cfg.MaxAge <= 0 {
+		return nil
+	}
+	segs, err := segments(l.dir)
+	if err != nil {
+		return err
+	}
+	for i, s := range segs {
+		if s.seq >= active {
+			break // never remove the active segment
+		}
+		overCount := l.cfg.MaxSegments > 0 && len(segs)-i > l.cfg.MaxSegments
+		overAge := l.cfg.MaxAge > 0 && time.Since(s.modTime) > l.cfg.MaxAge
+		if !overCount && !overAge {
+			continue
+		}
+		// remove both versions, compression could be interrupted after rename
+		os.Remove(l.path(s.seq, segmentExt))
+		if l.cfg.Codec != nil {
+			os.Remove(l.path(s.seq, segmentExt+l.cfg.Codec.Ext()))
+		}
+		os.Remove(filepath.Join(l.dir, s.name))
+		os.Remove(l.path(s.seq, indexExt))
+	}
+	return nil
+}
+
+// Scan calls fn for each message in the log matching the query, in the order
+// they are appended. Stops on first fn error.
+func (l *SegmentLog) Scan(q SegmentQuery, fn func(*uof.Message) error) error {
+	if err := l.Flush(); err != nil {
+		return uof.Notice("segment log flush", err)
+	}
+	l.files.Lock()
+	defer l.files.Unlock()
+	return ScanSegmentLog(l.dir, l.cfg.Codec, q, fn)
+}
+
+// ScanSegmentLog reads segment log in the dir without opening it for
+// writing. Codec is required if segments are compressed with other than gzip
+// or zstd.
+func ScanSegmentLog(dir string, codec SegmentCodec, q SegmentQuery, fn func(*uof.Message) error) error {
+	segs, err := segments(dir)
+	if err != nil {
+		return uof.Notice("segment log scan", err)
+	}
+	for i, s := range segs {
+		offsets, err := readIndex(filepath.Join(dir, segmentName(s.seq)+indexExt), q)
+		if err != nil {
+			return uof.Notice("segment log index", err)
+		}
+		if len(offsets) == 0 {
+			continue
+		}
+		// last segment can be active, with the tail record not yet written
+		tail := i == len(segs)-1
+		if err := scanSegment(filepath.Join(dir, s.name), codec, offsets, tail, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readIndex returns offsets of the records matching query
+func readIndex(name string, q SegmentQuery) (map[int]struct{}, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // removed by retention
+		}
+		return nil, err
+	}
+	defer f.Close()
+	offsets := make(map[int]struct{})
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		p := strings.Fields(s.Text())
+		if len(p) != 3 {
+			continue // incomplete line of the active segment
+		}
+		receivedAt, _ := strconv.Atoi(p[0])
+		urn := uof.URN(p[1])
+		if p[1] == "-" {
+			urn = uof.NoURN
+		}
+		if !q.match(receivedAt, urn) {
+			continue
+		}
+		if offset, err := strconv.Atoi(p[2]); err == nil {
+			offsets[offset] = struct{}{}
+		}
+	}
+	return offsets, s.Err()
+}
+
+func scanSegment(name string, codec SegmentCodec, offsets map[int]struct{}, tail bool, fn func(*uof.Message) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return uof.Notice("segment log scan", err)
+	}
+	defer f.Close()
+	var r io.Reader = bufio.NewReader(f)
+	if !strings.HasSuffix(name, segmentExt) {
+		if codec == nil {
+			for _, c := range []SegmentCodec{GzipCodec, ZstdCodec} {
+				if strings.HasSuffix(name, c.Ext()) {
+					codec = c
+				}
+			}
+		}
+		if codec == nil {
+			return uof.Notice("segment log scan", fmt.Errorf("no codec for %s", name))
+		}
+		cr, err := codec.NewReader(r)
+		if err != nil {
+			return uof.Notice("segment log scan", err)
+		}
+		defer cr.Close()
+		r = cr
+	}
+
+	offset := 0
+	var hdr [4]byte
+	truncated := func(err error) error {
+		if tail && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+			return nil // end of the active segment
+		}
+		return segmentCorrupt(name, offset, err)
+	}
+	for len(offsets) > 0 {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF {
+				return nil // end of segment
+			}
+			return truncated(err)
+		}
+		size := int(binary.BigEndian.Uint32(hdr[:]))
+		_, match := offsets[offset]
+		if !match {
+			if _, err := io.CopyN(ioutil.Discard, r, int64(size)); err != nil {
+				return truncated(err)
+			}
+			offset += len(hdr) + size
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return truncated(err)
+		}
+		delete(offsets, offset)
+		m := &uof.Message{}
+		if err := m.Unmarshal(buf); err != nil {
+			return segmentCorrupt(name, offset, err)
+		}
+		offset += len(hdr) + size
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrSegmentCorrupt is returned from the scan when the record of the closed
+// segment is truncated or can't be decoded.
+var ErrSegmentCorrupt = errors.New("segment corrupt")
+
+func segmentCorrupt(name string, offset int, err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return uof.Notice("segment log scan",
+		fmt.Errorf("%w: %s at offset %d: %v", ErrSegmentCorrupt, filepath.Base(name), offset, err))
+}
+
+type segmentFile struct {
+	seq     int
+	name    string
+	modTime time.Time
+}
+
+// segments lists segment files in the dir ordered by sequence
+func segments(dir string) ([]segmentFile, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	bySeq := make(map[int]segmentFile)
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || strings.HasSuffix(name, tmpExt) || strings.HasSuffix(name, indexExt) {
+			continue
+		}
+		i := strings.Index(name, segmentExt)
+		if i <= 0 {
+			continue
+		}
+		seq, err := strconv.Atoi(name[:i])
+		if err != nil {
+			continue
+		}
+		// during compression both files can exists, prefer uncompressed one
+		if s, ok := bySeq[seq]; ok && strings.HasSuffix(s.name, segmentExt) {
+			continue
+		}
+		bySeq[seq] = segmentFile{seq: seq, name: name, modTime: fi.ModTime()}
+	}
+	segs := make([]segmentFile, 0, len(bySeq))
+	for _, s := range bySeq {
+		segs = append(segs, s)
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].seq < segs[j].seq })
+	return segs, nil
+}
+
+// SegmentStore appends all messages to the segment log. It replaces
+// InnerFileStore when one file per message is not acceptable.
+// Log is closed when the pipeline is closed.
+func SegmentStore(l *SegmentLog) InnerStage {
+	return Stage(func(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) {
+		for m := range in {
+			if err := l.Append(m); err != nil {
+				errc <- err
+			}
+			for _, err := range l.takeErrors() {
+				errc <- err
+			}
+			out <- m
+		}
+		if err := l.Close(); err != nil {
+			errc <- uof.Notice("segment log close", err)
+		}
+		for _, err := range l.takeErrors() {
+			errc <- err
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package pipe
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "segments")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := OpenSegmentLog(dir, SegmentLogConfig{
+		MaxSegmentSize: 1,
+		Codec:          GzipCodec,
+		MaxSegments:    3,
+	})
+	assert.NoError(t, err)
+
+	var msgs []*uof.Message
+	for i := 0; i < 5; i++ {
+		m := fixtureChangeMsg(t)
+		if i%2 == 1 {
+			m = uof.NewConnnectionMessage(uof.ConnectionStatusUp)
+		}
+		m.ReceivedAt = 1000 + i
+		assert.NoError(t, l.Append(m))
+		msgs = append(msgs, m)
+	}
+	assert.NoError(t, l.Close())
+
+	segs, err := segments(dir)
+	assert.NoError(t, err)
+	assert.Len(t, segs, 3) // one message per segment, retention keeps last 3
+	assert.Equal(t, "00000003.log.gz", segs[0].name)
+	assert.Equal(t, "00000005.log", segs[2].name)
+
+	scan := func(q SegmentQuery) []*uof.Message {
+		var found []*uof.Message
+		err := ScanSegmentLog(dir, GzipCodec, q, func(m *uof.Message) error {
+			found = append(found, m)
+			return nil
+		})
+		assert.NoError(t, err)
+		return found
+	}
+	found := scan(SegmentQuery{})
+	assert.Len(t, found, 3)
+	assert.Equal(t, msgs[2].ReceivedAt, found[0].ReceivedAt)
+	assert.Equal(t, msgs[4].ReceivedAt, found[2].ReceivedAt)
+
+	found = scan(SegmentQuery{EventURN: "sr:match:1234"})
+	assert.Len(t, found, 2)
+	assert.Equal(t, uof.MessageTypeFixtureChange, found[0].Type)
+	assert.NotNil(t, found[0].FixtureChange)
+
+	found = scan(SegmentQuery{From: msgs[3].ReceivedAt, To: msgs[4].ReceivedAt})
+	assert.Len(t, found, 1)
+	assert.Equal(t, uof.ConnectionStatusUp, found[0].Connection.Status)
+
+	// reopen continues after existing segments
+	l, err = OpenSegmentLog(dir, SegmentLogConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, l.seq)
+	assert.NoError(t, l.Append(msgs[0]))
+	found = nil
+	assert.NoError(t, l.Scan(SegmentQuery{}, func(m *uof.Message) error {
+		found = append(found, m)
+		return nil
+	}))
+	assert.Len(t, found, 4)
+	assert.NoError(t, l.Close())
+}
+
+func TestSegmentLogCorrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "segments")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := OpenSegmentLog(dir, SegmentLogConfig{MaxSegmentSize: 1})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Append(fixtureChangeMsg(t)))
+	}
+	assert.NoError(t, l.Close())
+	truncate := func(seq int) {
+		name := l.path(seq, segmentExt)
+		fi, err := os.Stat(name)
+		assert.NoError(t, err)
+		assert.NoError(t, os.Truncate(name, fi.Size()-3))
+	}
+	scan := func() (int, error) {
+		found := 0
+		err := ScanSegmentLog(dir, nil, SegmentQuery{}, func(m *uof.Message) error {
+			found++
+			return nil
+		})
+		return found, err
+	}
+
+	// not fully written record at the end of the last segment
+	truncate(3)
+	found, err := scan()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found)
+
+	// reopen repairs and compresses the last segment of the previous run
+	l, err = OpenSegmentLog(dir, SegmentLogConfig{Codec: GzipCodec})
+	assert.NoError(t, err)
+	assert.NoError(t, l.Close())
+	segs, err := segments(dir)
+	assert.NoError(t, err)
+	assert.Len(t, segs, 4)
+	assert.Equal(t, "00000003.log.gz", segs[2].name)
+	found, err = scan()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found)
+
+	// truncated closed segment is corrupt
+	truncate(1)
+	found, err = scan()
+	assert.True(t, errors.Is(err, ErrSegmentCorrupt))
+	assert.Equal(t, 0, found)
+}
+
+func TestSegmentStoreCompressError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "segments")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := OpenSegmentLog(dir, SegmentLogConfig{MaxSegmentSize: 1, Codec: failingCodec{}})
+	assert.NoError(t, err)
+
+	in := make(chan *uof.Message)
+	out, errc := SegmentStore(l)(in)
+	go func() {
+		for range out {
+		}
+	}()
+	in <- fixtureChangeMsg(t)
+	in <- fixtureChangeMsg(t)
+	close(in)
+
+	var errs []error
+	for err := range errc {
+		errs = append(errs, err)
+	}
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "compress")
+}
+
+type failingCodec struct{}
+
+func (failingCodec) Ext() string { return ".bad" }
+func (failingCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nil, errors.New("codec failed")
+}
+func (failingCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return nil, errors.New("codec failed")
+}
+
+func TestSegmentLogZstd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "segments")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := OpenSegmentLog(dir, SegmentLogConfig{MaxSegmentSize: 1, Codec: ZstdCodec})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Append(fixtureChangeMsg(t)))
+	}
+	assert.NoError(t, l.Close())
+
+	segs, err := segments(dir)
+	assert.NoError(t, err)
+	assert.Len(t, segs, 3)
+	assert.Equal(t, "00000001.log.zst", segs[0].name)
+
+	// codec is found from the file extension
+	found := 0
+	assert.NoError(t, ScanSegmentLog(dir, nil, SegmentQuery{}, func(m *uof.Message) error {
+		assert.NotNil(t, m.FixtureChange)
+		found++
+		return nil
+	}))
+	assert.Equal(t, 3, found)
+}
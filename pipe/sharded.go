@@ -0,0 +1,100 @@
+package pipe
+
+import (
+	"sync"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// ShardedConsumer runs consumer in multiple goroutines (shards).
+//
+// Event messages are partitioned by EventID so all messages of one event are
+// handled by the same shard in the order they are received. System and
+// lexicon messages are sent to all shards. Message is shared between shards,
+// consumer should not modify it.
+type ShardedConsumer struct {
+	consumer ConsumerStage
+	shards   []chan *uof.Message
+}
+
+// NewShardedConsumer creates consumer with number of shards, each with `in`
+// chan buffered to the size of buffer.
+func NewShardedConsumer(consumer ConsumerStage, shards, buffer int) *ShardedConsumer {
+	if shards < 1 {
+		shards = 1
+	}
+	s := &ShardedConsumer{consumer: consumer}
+	for i := 0; i < shards; i++ {
+		s.shards = append(s.shards, make(chan *uof.Message, buffer))
+	}
+	return s
+}
+
+// Depths returns number of messages waiting in each shard queue.
+func (s *ShardedConsumer) Depths() []int {
+	d := make([]int, len(s.shards))
+	for i, c := range s.shards {
+		d[i] = len(c)
+	}
+	return d
+}
+
+// Shard returns index of the shard for the event.
+func (s *ShardedConsumer) Shard(eventID int) int {
+	if eventID < 0 {
+		eventID = -eventID
+	}
+	return eventID % len(s.shards)
+}
+
+// Stage of the pipeline which passes all messages to the next stage and
+// to the shards.
+func (s *ShardedConsumer) Stage() InnerStage {
+	return func(in <-chan *uof.Message) (<-chan *uof.Message, <-chan error) {
+		out := make(chan *uof.Message)
+		errc := make(chan error, len(s.shards))
+
+		go func() { // tee in to out and shards
+			defer close(out)
+			defer func() {
+				for _, c := range s.shards {
+					close(c)
+				}
+			}()
+			for m := range in {
+				s.dispatch(m)
+				out <- m
+			}
+		}()
+
+		var wg sync.WaitGroup
+		wg.Add(len(s.shards))
+		for _, c := range s.shards {
+			go func(c chan *uof.Message) {
+				defer wg.Done()
+				if err := s.consumer(c); err != nil {
+					errc <- err
+				}
+				go func() { // for unclean exit; drain this chan
+					for range c {
+					}
+				}()
+			}(c)
+		}
+		go func() {
+			wg.Wait()
+			close(errc)
+		}()
+		return out, errc
+	}
+}
+
+func (s *ShardedConsumer) dispatch(m *uof.Message) {
+	if m.Type.Kind() == uof.MessageKindEvent {
+		s.shards[s.Shard(m.EventID)] <- m
+		return
+	}
+	for _, c := range s.shards {
+		c <- m
+	}
+}
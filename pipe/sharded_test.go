@@ -0,0 +1,60 @@
+package pipe
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedConsumer(t *testing.T) {
+	var mu sync.Mutex
+	var received [][]*uof.Message
+	sc := NewShardedConsumer(func(in <-chan *uof.Message) error {
+		var ms []*uof.Message
+		for m := range in {
+			ms = append(ms, m)
+		}
+		mu.Lock()
+		received = append(received, ms)
+		mu.Unlock()
+		return nil
+	}, 3, 16)
+
+	in := make(chan *uof.Message, 16)
+	in <- uof.NewConnnectionMessage(uof.ConnectionStatusUp)
+	for i := 0; i < 12; i++ {
+		buf := []byte(fmt.Sprintf(`<fixture_change event_id="sr:match:%d" product="3" start_time="%d"/>`, i%6+1, i+1))
+		m, err := uof.NewQueueMessage(fmt.Sprintf("hi.pre.-.fixture_change.1.sr:match.%d.-", i%6+1), buf)
+		assert.NoError(t, err)
+		in <- m
+	}
+	close(in)
+
+	out, errc := sc.Stage()(in)
+	cnt := 0
+	for range out {
+		cnt++
+	}
+	for err := range errc {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 13, cnt)
+	assert.Equal(t, []int{0, 0, 0}, sc.Depths())
+
+	assert.Len(t, received, 3)
+	for _, ms := range received {
+		assert.Len(t, ms, 5)
+		assert.Equal(t, uof.MessageTypeConnection, ms[0].Type)
+		shard := sc.Shard(ms[1].EventID)
+		last := make(map[int]int)
+		for _, m := range ms[1:] {
+			assert.Equal(t, shard, sc.Shard(m.EventID))
+			ts := *m.FixtureChange.StartTime
+			assert.True(t, ts > last[m.EventID])
+			last[m.EventID] = ts
+		}
+	}
+}
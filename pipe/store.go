@@ -1,9 +1,16 @@
 package pipe
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path"
+	"path/filepath"
+	"sort"
 
 	"os"
 	"sync"
@@ -69,7 +76,7 @@ func filename(m *uof.Message) string {
 			}
 			return fmt.Sprintf("/state/%s/fixtures/%s", m.Lang, m.EventURN)
 		case uof.MessageTypeCompetitor:
-			return fmt.Sprintf("/state/%s/competitors/%08d/%13d", m.Lang, m.Competitor.ID, m.RequestedAt)
+			return fmt.Sprintf("/state/%s/competitors/%08d/%13d", m.Lang, m.Competitor.Competitor.ID, m.RequestedAt)
 		case uof.MessageTypeTournament:
 			return fmt.Sprintf("/state/%s/tournaments/%s", m.Lang, m.EventURN)
 		}
@@ -87,3 +94,106 @@ func save(filename string, buf []byte) error {
 	}
 	return ioutil.WriteFile(filename, buf, 0644)
 }
+
+// FileStoreSource replays messages saved by FileStore or InnerFileStore in the
+// root directory. Messages from all files are merged in ReceivedAt order.
+// Replay stops when ctx is done.
+//
+// Use it as source of the pipeline to replay stored session without any
+// Betradar connection:
+//   pipe.Build(pipe.FileStoreSource(ctx, "./tmp"), pipe.Consumer(myConsumer))
+func FileStoreSource(ctx context.Context, root string) SourceStage {
+	return func() (<-chan *uof.Message, <-chan error) {
+		out := make(chan *uof.Message)
+		errc := make(chan error)
+		go func() {
+			defer close(out)
+			defer close(errc)
+
+			files, err := storedFiles(ctx, root, errc)
+			if err != nil {
+				if err != ctx.Err() {
+					errc <- uof.Notice("file store walk", err)
+				}
+				return
+			}
+			for _, f := range files {
+				m, err := load(f.name)
+				if err != nil {
+					errc <- uof.Notice("file store load", err)
+					continue
+				}
+				select {
+				case out <- m:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return out, errc
+	}
+}
+
+type storedFile struct {
+	name       string
+	receivedAt int
+}
+
+// storedFiles finds all files in the root and sorts them by the ReceivedAt
+// of the message. Only the first line with the message header is read here,
+// whole file is loaded when the message is sent.
+func storedFiles(ctx context.Context, root string, errc chan<- error) ([]storedFile, error) {
+	var files []storedFile
+	err := filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		h, err := loadHeader(name)
+		if err != nil {
+			errc <- uof.Notice("file store load", err)
+			return nil
+		}
+		files = append(files, storedFile{name: name, receivedAt: h.ReceivedAt})
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].receivedAt < files[j].receivedAt
+	})
+	return files, err
+}
+
+// loadHeader decodes only the first line of the stored message
+func loadHeader(name string) (*uof.Header, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var h uof.Header
+	if err := json.Unmarshal(bytes.TrimSpace(buf), &h); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &h, nil
+}
+
+func load(name string) (*uof.Message, error) {
+	buf, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	m := &uof.Message{}
+	if err := m.Unmarshal(buf); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
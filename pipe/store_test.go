@@ -0,0 +1,71 @@
+package pipe
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStoreSource(t *testing.T) {
+	root, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	oc := oddsChangeMessage(t)
+	fc := fixtureChangeMsg(t)
+	cm := uof.NewConnnectionMessage(uof.ConnectionStatusUp)
+	// save in different order than received
+	in := make(chan *uof.Message, 3)
+	in <- cm
+	in <- fc
+	in <- oc
+	close(in)
+	assert.NoError(t, FileStore(root)(in))
+
+	out, errc := FileStoreSource(context.Background(), root)()
+	go func() {
+		for err := range errc {
+			assert.NoError(t, err)
+		}
+	}()
+	var msgs []*uof.Message
+	for m := range out {
+		msgs = append(msgs, m)
+	}
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, oc.ReceivedAt, msgs[0].ReceivedAt)
+	assert.Equal(t, fc.ReceivedAt, msgs[1].ReceivedAt)
+	assert.Equal(t, cm.ReceivedAt, msgs[2].ReceivedAt)
+
+	assert.Equal(t, uof.MessageTypeOddsChange, msgs[0].Type)
+	assert.Equal(t, len(oc.OddsChange.Markets), len(msgs[0].OddsChange.Markets))
+	assert.Equal(t, fc.FixtureChange.EventURN, msgs[1].FixtureChange.EventURN)
+	assert.Equal(t, uof.ConnectionStatusUp, msgs[2].Connection.Status)
+}
+
+func TestFileStoreSourceCancel(t *testing.T) {
+	root, err := ioutil.TempDir("", "store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	in := make(chan *uof.Message, 2)
+	in <- oddsChangeMessage(t)
+	in <- fixtureChangeMsg(t)
+	close(in)
+	assert.NoError(t, FileStore(root)(in))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errc := FileStoreSource(ctx, root)()
+	<-out
+	cancel()
+	for range out {
+		t.Fatal("unexpected message")
+	}
+	for err := range errc {
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,138 @@
+package pipe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+type summaryAPI interface {
+	Summary(lang uof.Lang, eventURN uof.URN) (*uof.Summary, error)
+}
+
+// SummaryConfig configures summary stage.
+type SummaryConfig struct {
+	// Statuses of the event on which summary is fetched. Default are ended
+	// and closed.
+	Statuses []uof.EventStatus
+}
+
+type summaryKey struct {
+	eventID int
+	lang    uof.Lang
+}
+
+// summaryEvent is the last known status of the event and statuses on which
+// summary is already fetched
+type summaryEvent struct {
+	status    uof.EventStatus
+	fetched   []uof.EventStatus
+	changedAt time.Time
+}
+
+type summary struct {
+	api       summaryAPI
+	languages []uof.Lang
+	statuses  []uof.EventStatus
+	events    map[summaryKey]summaryEvent
+	cleanedAt time.Time
+	errc      chan<- error
+	out       chan<- *uof.Message
+	rateLimit chan struct{}
+	subProcs  *sync.WaitGroup
+}
+
+// Summary stage fetches event summary when the event status from the odds
+// change transitions to one of the configured statuses. Unlike fixture stage
+// it works for events of all producers. Status is tracked for each event and
+// language regardless of the producer, summary is fetched once for each
+// status.
+func Summary(api summaryAPI, languages []uof.Lang, cfg SummaryConfig) InnerStage {
+	if len(cfg.Statuses) == 0 {
+		cfg.Statuses = []uof.EventStatus{uof.EventStatusEnded, uof.EventStatusClosed}
+	}
+	s := &summary{
+		api:       api,
+		languages: languages,
+		statuses:  cfg.Statuses,
+		events:    make(map[summaryKey]summaryEvent),
+		subProcs:  &sync.WaitGroup{},
+		rateLimit: make(chan struct{}, ConcurentAPICallsLimit),
+	}
+	return StageWithSubProcessesSync(s.loop)
+}
+
+func (s *summary) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) *sync.WaitGroup {
+	s.errc, s.out = errc, out
+
+	for m := range in {
+		out <- m
+		if m.Is(uof.MessageTypeOddsChange) && m.OddsChange.EventStatus != nil {
+			now := time.Now()
+			for _, lang := range s.languages {
+				key := summaryKey{eventID: m.EventID, lang: lang}
+				if s.transition(key, m.OddsChange.EventStatus.Status, now) {
+					s.get(m.EventURN, lang, m.ReceivedAt)
+				}
+			}
+		}
+	}
+	return s.subProcs
+}
+
+// transition returns true when the event status is changed to one of the
+// configured statuses on which summary is not fetched yet
+func (s *summary) transition(key summaryKey, status uof.EventStatus, now time.Time) bool {
+	s.cleanup(now)
+	e, found := s.events[key]
+	if found && e.status == status {
+		return false
+	}
+	e.status, e.changedAt = status, now
+	fetch := containsStatus(s.statuses, status) && !containsStatus(e.fetched, status)
+	if fetch {
+		e.fetched = append(e.fetched, status)
+	}
+	s.events[key] = e
+	return fetch
+}
+
+func containsStatus(statuses []uof.EventStatus, status uof.EventStatus) bool {
+	for _, st := range statuses {
+		if st == status {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup removes events without status change in the retention, closed
+// events are kept until then so that repeated close is not fetched again
+func (s *summary) cleanup(now time.Time) {
+	if now.Sub(s.cleanedAt) < time.Minute {
+		return
+	}
+	s.cleanedAt = now
+	for k, e := range s.events {
+		if now.Sub(e.changedAt) > closedEventRetention {
+			delete(s.events, k)
+		}
+	}
+}
+
+func (s *summary) get(eventURN uof.URN, lang uof.Lang, requestedAt int) {
+	s.subProcs.Add(1)
+	go func() {
+		defer s.subProcs.Done()
+		s.rateLimit <- struct{}{}
+		defer func() { <-s.rateLimit }()
+
+		x, err := s.api.Summary(lang, eventURN)
+		if err != nil {
+			s.errc <- err
+			return
+		}
+		s.out <- uof.NewSummaryMessage(lang, *x, requestedAt)
+	}()
+}
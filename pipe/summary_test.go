@@ -0,0 +1,96 @@
+package pipe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+type summaryAPIMock struct {
+	calls int
+	sync.Mutex
+}
+
+func (m *summaryAPIMock) Summary(lang uof.Lang, eventURN uof.URN) (*uof.Summary, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.calls++
+	return &uof.Summary{SportEvent: uof.Fixture{ID: eventURN.EventID(), URN: eventURN}}, nil
+}
+
+func TestSummaryPipe(t *testing.T) {
+	a := &summaryAPIMock{}
+	s := Summary(a, []uof.Lang{uof.LangEN, uof.LangDE}, SummaryConfig{})
+	in := make(chan *uof.Message)
+	out, _ := s(in)
+
+	var summaries []*uof.Message
+	cnt := 0
+	done := make(chan struct{})
+	go func() {
+		for m := range out {
+			cnt++
+			if m.Is(uof.MessageTypeSummary) {
+				summaries = append(summaries, m)
+			}
+		}
+		close(done)
+	}()
+
+	in <- statusMsg(t, 1, 6)
+	in <- statusMsg(t, 3, 100) // ended
+	in <- statusMsg(t, 3, 100)
+	in <- statusMsg(t, 4, 100) // closed
+	in <- statusMsg(t, 4, 100) // repeated close, from recovery
+	close(in)
+	<-done
+
+	assert.Equal(t, 4, a.calls)
+	assert.Equal(t, 9, cnt)
+	assert.Len(t, summaries, 4)
+	assert.Equal(t, 1, summaries[0].EventID)
+	assert.NotNil(t, summaries[0].Summary)
+}
+
+func TestSummaryTransition(t *testing.T) {
+	s := &summary{
+		statuses: []uof.EventStatus{uof.EventStatusEnded, uof.EventStatusClosed},
+		events:   make(map[summaryKey]summaryEvent),
+	}
+	now := time.Now()
+	en := summaryKey{eventID: 1, lang: uof.LangEN}
+	de := summaryKey{eventID: 1, lang: uof.LangDE}
+	assert.True(t, s.transition(en, uof.EventStatusEnded, now))
+	assert.False(t, s.transition(en, uof.EventStatusEnded, now))
+	// other producer still reports live, then ended again
+	assert.False(t, s.transition(en, uof.EventStatusLive, now))
+	assert.False(t, s.transition(en, uof.EventStatusEnded, now))
+	assert.True(t, s.transition(en, uof.EventStatusClosed, now))
+	// each language is tracked separately
+	assert.True(t, s.transition(de, uof.EventStatusClosed, now))
+
+	now = now.Add(closedEventRetention + time.Minute)
+	s.cleanup(now)
+	assert.Len(t, s.events, 0)
+}
+
+func TestSummaryProducers(t *testing.T) {
+	a := &summaryAPIMock{}
+	s := Summary(a, []uof.Lang{uof.LangEN}, SummaryConfig{})
+	in := make(chan *uof.Message, 2)
+	out, _ := s(in)
+
+	pm, err := uof.NewQueueMessage("hi.pre.-.odds_change.1.sr:match.1.-",
+		[]byte(`<odds_change event_id="sr:match:1" timestamp="2" product="3"><sport_event_status status="4"/></odds_change>`))
+	assert.NoError(t, err)
+	in <- statusMsg(t, 4, 100)
+	in <- pm
+	close(in)
+	for range out {
+	}
+	// closed from live odds and ctrl is fetched once
+	assert.Equal(t, 1, a.calls)
+}
@@ -0,0 +1,165 @@
+package pipe
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// timelineRetention is how long live event without status changes is
+// tracked
+const timelineRetention = 6 * time.Hour
+
+type timelineAPI interface {
+	Timeline(lang uof.Lang, eventURN uof.URN) (*uof.MatchTimeline, error)
+}
+
+// timelineKey is the part of the sport event status which triggers timeline
+// fetch when changed
+type timelineKey struct {
+	status      uof.EventStatus
+	matchStatus int
+	homeScore   int
+	awayScore   int
+}
+
+type timelineEvent struct {
+	key     timelineKey
+	fetched time.Time // of the last fetch
+	seen    time.Time // of the last status change
+	pending bool      // trailing fetch is scheduled
+}
+
+type timeline struct {
+	api       timelineAPI
+	languages []uof.Lang
+	interval  time.Duration
+	events    map[int]*timelineEvent
+	cleanedAt time.Time
+	errc      chan<- error
+	out       chan<- *uof.Message
+	rateLimit chan struct{}
+	subProcs  *sync.WaitGroup
+	sync.Mutex
+}
+
+// Timeline stage fetches match timeline of the live event when event status,
+// match status or score in the odds change is changed. Timeline is fetched at
+// most once in the interval for each event, changes within the interval
+// result in one fetch at its end. Final timeline is fetched when the event
+// is no longer live.
+func Timeline(api timelineAPI, languages []uof.Lang, interval time.Duration) InnerStage {
+	t := &timeline{
+		api:       api,
+		languages: languages,
+		interval:  interval,
+		events:    make(map[int]*timelineEvent),
+		subProcs:  &sync.WaitGroup{},
+		rateLimit: make(chan struct{}, ConcurentAPICallsLimit),
+	}
+	return StageWithSubProcessesSync(t.loop)
+}
+
+func (t *timeline) loop(in <-chan *uof.Message, out chan<- *uof.Message, errc chan<- error) *sync.WaitGroup {
+	t.errc, t.out = errc, out
+
+	for m := range in {
+		out <- m
+		if m.Is(uof.MessageTypeOddsChange) && m.OddsChange.EventStatus != nil {
+			t.apply(m.EventID, m.EventURN, m.OddsChange.EventStatus, m.ReceivedAt)
+		}
+	}
+	return t.subProcs
+}
+
+func (t *timeline) apply(eventID int, eventURN uof.URN, s *uof.SportEventStatus, requestedAt int) {
+	key := timelineKey{
+		status:      s.Status,
+		matchStatus: intValue(s.MatchStatus),
+		homeScore:   intValue(s.HomeScore),
+		awayScore:   intValue(s.AwayScore),
+	}
+	live := uof.EventState{Status: s.Status}.Live()
+	now := time.Now()
+
+	t.Lock()
+	defer t.Unlock()
+	t.cleanup(now)
+	e, found := t.events[eventID]
+	if !found {
+		if !live {
+			return
+		}
+		e = &timelineEvent{key: key}
+		t.events[eventID] = e
+	} else {
+		if e.key == key {
+			return
+		}
+		e.key = key
+	}
+	e.seen = now
+	if !live {
+		delete(t.events, eventID)
+	}
+
+	if e.pending {
+		return
+	}
+	wait := t.interval - time.Since(e.fetched)
+	if wait <= 0 || !live {
+		e.fetched = time.Now()
+		t.get(eventURN, requestedAt)
+		return
+	}
+	e.pending = true
+	t.subProcs.Add(1)
+	time.AfterFunc(wait, func() {
+		defer t.subProcs.Done()
+		t.Lock()
+		e.pending = false
+		e.fetched = time.Now()
+		t.Unlock()
+		t.get(eventURN, requestedAt)
+	})
+}
+
+// cleanup removes events without status changes in the retention, at most
+// once an hour, lock must be held
+func (t *timeline) cleanup(now time.Time) {
+	if now.Sub(t.cleanedAt) < time.Hour {
+		return
+	}
+	t.cleanedAt = now
+	for id, e := range t.events {
+		if now.Sub(e.seen) > timelineRetention {
+			delete(t.events, id)
+		}
+	}
+}
+
+func (t *timeline) get(eventURN uof.URN, requestedAt int) {
+	t.subProcs.Add(len(t.languages))
+	for _, lang := range t.languages {
+		go func(lang uof.Lang) {
+			defer t.subProcs.Done()
+			t.rateLimit <- struct{}{}
+			defer func() { <-t.rateLimit }()
+
+			tl, err := t.api.Timeline(lang, eventURN)
+			if err != nil {
+				t.errc <- err
+				return
+			}
+			t.out <- uof.NewTimelineMessage(lang, tl, requestedAt)
+		}(lang)
+	}
+}
+
+func intValue(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
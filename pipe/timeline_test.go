@@ -0,0 +1,67 @@
+package pipe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+type timelineAPIMock struct {
+	calls int
+	sync.Mutex
+}
+
+func (m *timelineAPIMock) Timeline(lang uof.Lang, eventURN uof.URN) (*uof.MatchTimeline, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.calls++
+	return &uof.MatchTimeline{SportEvent: uof.Fixture{ID: eventURN.EventID(), URN: eventURN}}, nil
+}
+
+func TestTimelinePipe(t *testing.T) {
+	a := &timelineAPIMock{}
+	tl := Timeline(a, []uof.Lang{uof.LangEN}, 50*time.Millisecond)
+	in := make(chan *uof.Message)
+	out, _ := tl(in)
+
+	var timelines []*uof.Message
+	cnt := 0
+	done := make(chan struct{})
+	go func() {
+		for m := range out {
+			cnt++
+			if m.Is(uof.MessageTypeTimeline) {
+				timelines = append(timelines, m)
+			}
+		}
+		close(done)
+	}()
+
+	in <- statusMsg(t, 0, 0) // not started, not fetched
+	in <- statusMsg(t, 1, 6) // fetched
+	in <- statusMsg(t, 1, 6) // no change
+	in <- statusMsg(t, 1, 7) // throttled, trailing fetch
+	in <- statusMsg(t, 1, 31)
+	in <- statusMsg(t, 3, 100) // ended within the same interval
+	close(in)
+	<-done
+
+	assert.Equal(t, 2, a.calls)
+	assert.Equal(t, 8, cnt)
+	assert.Len(t, timelines, 2)
+	assert.Equal(t, 1, timelines[0].EventID)
+	assert.Equal(t, uof.LangEN, timelines[0].Lang)
+}
+
+func TestTimelineCleanup(t *testing.T) {
+	tl := &timeline{events: make(map[int]*timelineEvent)}
+	now := time.Now()
+	tl.events[1] = &timelineEvent{seen: now.Add(-timelineRetention - time.Minute)}
+	tl.events[2] = &timelineEvent{seen: now.Add(-time.Minute)}
+	tl.cleanup(now)
+	assert.Len(t, tl.events, 1)
+	assert.NotNil(t, tl.events[2])
+}
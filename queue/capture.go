@@ -0,0 +1,128 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/streadway/amqp"
+)
+
+// Delivery is raw amqp delivery as stored in the capture file.
+type Delivery struct {
+	RoutingKey string                 `json:"routingKey"`
+	Headers    map[string]interface{} `json:"headers,omitempty"`
+	Timestamp  int                    `json:"timestamp"` // message ReceivedAt, in milliseconds
+	Body       []byte                 `json:"body"`
+}
+
+// Capture records every delivery from the queue into an append-only file. One
+// json encoded Delivery per line.
+type Capture struct {
+	f   *os.File
+	enc *json.Encoder
+	sync.Mutex
+}
+
+// NewCapture opens (or creates) capture file for appending.
+func NewCapture(path string) (*Capture, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, uof.Notice("capture.Open", err)
+	}
+	return &Capture{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (c *Capture) write(d amqp.Delivery, receivedAt int) error {
+	if c == nil {
+		return nil
+	}
+	r := Delivery{
+		RoutingKey: d.RoutingKey,
+		Timestamp:  receivedAt,
+		Body:       d.Body,
+	}
+	if len(d.Headers) > 0 {
+		r.Headers = make(map[string]interface{}, len(d.Headers))
+		for k, v := range d.Headers {
+			r.Headers[k] = v
+		}
+	}
+	c.Lock()
+	defer c.Unlock()
+	if err := c.enc.Encode(r); err != nil {
+		return uof.Notice("capture.Write", err)
+	}
+	return nil
+}
+
+// Close the capture file.
+func (c *Capture) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.f.Close()
+}
+
+// FromCapture is pipeline source which replays deliveries from the capture
+// file. Each delivery is parsed with uof.NewQueueMessage as it would be when
+// received from the queue, ReceivedAt is the captured one.
+//
+// Speed sets timing between messages: 1 replays with original timing, 10 is
+// ten times faster. Zero (or negative) replays without any delay. Replay stops
+// when ctx is done.
+func FromCapture(ctx context.Context, path string, speed float64) func() (<-chan *uof.Message, <-chan error) {
+	return func() (<-chan *uof.Message, <-chan error) {
+		out := make(chan *uof.Message)
+		errc := make(chan error)
+		go func() {
+			defer close(out)
+			defer close(errc)
+			f, err := os.Open(path)
+			if err != nil {
+				errc <- uof.Notice("capture.Open", err)
+				return
+			}
+			defer f.Close()
+			replay(ctx, f, speed, out, errc)
+		}()
+		return out, errc
+	}
+}
+
+func replay(ctx context.Context, r io.Reader, speed float64, out chan<- *uof.Message, errc chan<- error) {
+	var prev int
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for dec.More() {
+		var d Delivery
+		if err := dec.Decode(&d); err != nil {
+			errc <- uof.Notice("capture.Decode", err)
+			return
+		}
+		if speed > 0 && prev > 0 && d.Timestamp > prev {
+			select {
+			case <-time.After(time.Duration(float64(d.Timestamp-prev) * float64(time.Millisecond) / speed)):
+			case <-ctx.Done():
+				return
+			}
+		}
+		prev = d.Timestamp
+		m, err := uof.NewQueueMessage(d.RoutingKey, d.Body)
+		if err != nil {
+			errc <- uof.Notice("conn.DeliveryParse", err)
+			continue
+		}
+		if d.Timestamp > 0 {
+			m.ReceivedAt = d.Timestamp
+		}
+		select {
+		case out <- m:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "deliveries")
+
+	c, err := NewCapture(fn)
+	assert.NoError(t, err)
+	deliveries := []amqp.Delivery{
+		{
+			RoutingKey: "-.-.-.alive.-.-.-.-",
+			Headers:    amqp.Table{"timestamp_in_ms": "1564598513501"},
+			Body:       []byte(`<alive product="3" timestamp="1564598513501" subscribed="1"/>`),
+		},
+		{
+			RoutingKey: "hi.pre.-.fixture_change.1.sr:match.1234.-",
+			Body:       []byte(`<fixture_change event_id="sr:match:1234" product="3" start_time="1511107200000"/>`),
+		},
+		{
+			RoutingKey: "unknown",
+		},
+	}
+	for i, d := range deliveries {
+		assert.NoError(t, c.write(d, 1564598513501+i))
+	}
+	assert.NoError(t, c.Close())
+
+	f, err := os.Open(fn)
+	assert.NoError(t, err)
+	var d Delivery
+	assert.NoError(t, json.NewDecoder(f).Decode(&d))
+	f.Close()
+	assert.Equal(t, map[string]interface{}{"timestamp_in_ms": "1564598513501"}, d.Headers)
+	assert.Equal(t, 1564598513501, d.Timestamp)
+
+	out, errc := FromCapture(context.Background(), fn, 0)()
+	var msgs []*uof.Message
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		for err := range errc {
+			errs = append(errs, err)
+		}
+		close(done)
+	}()
+	for m := range out {
+		msgs = append(msgs, m)
+	}
+	<-done
+
+	assert.Len(t, msgs, 2)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, uof.MessageTypeAlive, msgs[0].Type)
+	assert.Equal(t, uof.ProducerPrematch, msgs[0].Alive.Producer)
+	assert.Equal(t, uof.MessageTypeFixtureChange, msgs[1].Type)
+	assert.Equal(t, uof.URN("sr:match:1234"), msgs[1].EventURN)
+	assert.Equal(t, 1564598513501, msgs[0].ReceivedAt)
+	assert.Equal(t, 1564598513502, msgs[1].ReceivedAt)
+
+	// replay with original timing is stopped by the context
+	ctx, cancel := context.WithCancel(context.Background())
+	out, errc = FromCapture(ctx, fn, 0.001)()
+	m := <-out
+	assert.Equal(t, uof.MessageTypeAlive, m.Type)
+	cancel()
+	for range out {
+		t.Fatal("unexpected message")
+	}
+	for err := range errc {
+		assert.NoError(t, err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"time"
 
 	"github.com/minus5/go-uof-sdk"
 	"github.com/streadway/amqp"
@@ -32,6 +33,60 @@ const (
 	bindingKeyRecoveryTemplate = "*.*.*.*.*.*.*.%d"
 )
 
+const (
+	defaultHeartbeat         = 10 * time.Second
+	defaultConnectionTimeout = 30 * time.Second
+)
+
+// Config tunes the amqp connection. Zero values are replaced with defaults.
+type Config struct {
+	// Heartbeat interval negotiated with the broker. Missing heartbeats are
+	// detected after two intervals and connection is closed.
+	Heartbeat time.Duration
+	// ConnectionTimeout limits tcp connect and tls handshake.
+	ConnectionTimeout time.Duration
+	// Prefetch is channel level limit of unacknowledged deliveries (basic.qos).
+	// When set deliveries are acknowledged after they are sent to the
+	// listener, so the broker stops delivering while the pipe is blocked. Zero
+	// means no limit and auto-ack consuming.
+	Prefetch int
+	// StatsInterval is the period of the connection statistics messages. Zero
+	// disables statistics messages.
+	StatsInterval time.Duration
+	// Reconnect policy used by WithReconnect.
+	Reconnect ReconnectPolicy
+	// Capture if set records every delivery.
+	Capture *Capture
+}
+
+func (c Config) withDefaults() Config {
+	if c.Heartbeat == 0 {
+		c.Heartbeat = defaultHeartbeat
+	}
+	if c.ConnectionTimeout == 0 {
+		c.ConnectionTimeout = defaultConnectionTimeout
+	}
+	return c
+}
+
+// Option sets attributes on the connection Config.
+type Option func(*Config)
+
+// WithConfig uses cfg for the connection.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) {
+		*c = cfg
+	}
+}
+
+func options(opts []Option) Config {
+	var c Config
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
 const (
 	BindAll int8 = iota
 	BindSports
@@ -40,40 +95,41 @@ const (
 	BindLive
 )
 
-// Dial connects to the queue chosen by environment
-func Dial(ctx context.Context, env uof.Environment, bookmakerID, token string, bind int8, nodeID int) (*Connection, error) {
+// Dial connects to the queue chosen by environment. Options tune the
+// connection, without them defaults are used.
+func Dial(ctx context.Context, env uof.Environment, bookmakerID, token string, bind int8, nodeID int, opts ...Option) (*Connection, error) {
 	switch env {
 	case uof.Replay:
-		return DialReplay(ctx, bookmakerID, token, bind, nodeID)
+		return DialReplay(ctx, bookmakerID, token, bind, nodeID, opts...)
 	case uof.Staging:
-		return DialStaging(ctx, bookmakerID, token, bind, nodeID)
+		return DialStaging(ctx, bookmakerID, token, bind, nodeID, opts...)
 	case uof.Production:
-		return DialProduction(ctx, bookmakerID, token, bind, nodeID)
+		return DialProduction(ctx, bookmakerID, token, bind, nodeID, opts...)
 	case uof.ProductionGlobal:
-		return DialProductionGlobal(ctx, bookmakerID, token, bind, nodeID)
+		return DialProductionGlobal(ctx, bookmakerID, token, bind, nodeID, opts...)
 	default:
 		return nil, uof.Notice("queue dial", fmt.Errorf("unknown environment %d", env))
 	}
 }
 
 // Dial connects to the production queue
-func DialProduction(ctx context.Context, bookmakerID, token string, bind int8, nodeID int) (*Connection, error) {
-	return dial(ctx, productionServer, bookmakerID, token, bind, nodeID)
+func DialProduction(ctx context.Context, bookmakerID, token string, bind int8, nodeID int, opts ...Option) (*Connection, error) {
+	return dial(ctx, productionServer, bookmakerID, token, bind, nodeID, options(opts))
 }
 
 // Dial connects to the production queue
-func DialProductionGlobal(ctx context.Context, bookmakerID, token string, bind int8, nodeID int) (*Connection, error) {
-	return dial(ctx, productionServerGlobal, bookmakerID, token, bind, nodeID)
+func DialProductionGlobal(ctx context.Context, bookmakerID, token string, bind int8, nodeID int, opts ...Option) (*Connection, error) {
+	return dial(ctx, productionServerGlobal, bookmakerID, token, bind, nodeID, options(opts))
 }
 
 // DialStaging connects to the staging queue
-func DialStaging(ctx context.Context, bookmakerID, token string, bind int8, nodeID int) (*Connection, error) {
-	return dial(ctx, stagingServer, bookmakerID, token, bind, nodeID)
+func DialStaging(ctx context.Context, bookmakerID, token string, bind int8, nodeID int, opts ...Option) (*Connection, error) {
+	return dial(ctx, stagingServer, bookmakerID, token, bind, nodeID, options(opts))
 }
 
 // DialReplay connects to the replay server
-func DialReplay(ctx context.Context, bookmakerID, token string, bind int8, nodeID int) (*Connection, error) {
-	return dial(ctx, replayServer, bookmakerID, token, bind, nodeID)
+func DialReplay(ctx context.Context, bookmakerID, token string, bind int8, nodeID int, opts ...Option) (*Connection, error) {
+	return dial(ctx, replayServer, bookmakerID, token, bind, nodeID, options(opts))
 }
 
 type Connection struct {
@@ -81,6 +137,8 @@ type Connection struct {
 	errs   <-chan *amqp.Error
 	reDial func() (*Connection, error)
 	info   ConnectionInfo
+	cfg    Config
+	stats  *stats
 }
 
 type ConnectionInfo struct {
@@ -96,6 +154,7 @@ func (c *Connection) Listen() (<-chan *uof.Message, <-chan error) {
 	go func() {
 		defer close(out)
 		defer close(errc)
+		defer c.stats.report(c.cfg.StatsInterval, out)()
 		c.drain(out, errc)
 	}()
 	return out, errc
@@ -112,18 +171,39 @@ func (c *Connection) drain(out chan<- *uof.Message, errc chan<- error) {
 		close(errsDone)
 	}()
 
-	for m := range c.msgs {
-		m, err := uof.NewQueueMessage(m.RoutingKey, m.Body)
+	for d := range c.msgs {
+		c.stats.delivery(len(d.Body))
+		m, err := uof.NewQueueMessage(d.RoutingKey, d.Body)
+		receivedAt := uof.CurrentTimestamp()
+		if m != nil {
+			receivedAt = m.ReceivedAt
+		}
+		if cerr := c.cfg.Capture.write(d, receivedAt); cerr != nil {
+			errc <- cerr
+		}
 		if err != nil {
+			c.stats.parseError()
 			errc <- uof.Notice("conn.DeliveryParse", err)
+			c.ack(d, errc)
 			continue
 		}
 		out <- m
+		c.ack(d, errc)
 	}
 	<-errsDone
 }
 
-func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nodeID int) (*Connection, error) {
+// ack delivery when consuming without auto-ack
+func (c *Connection) ack(d amqp.Delivery, errc chan<- error) {
+	if c.cfg.Prefetch <= 0 {
+		return
+	}
+	if err := d.Ack(false); err != nil {
+		errc <- uof.Notice("conn.Ack", err)
+	}
+}
+
+func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nodeID int, cfg Config) (*Connection, error) {
 	addr := fmt.Sprintf("amqps://%s:@%s//unifiedfeed/%s", token, server, bookmakerID)
 
 	var bindingKeys []string
@@ -144,11 +224,15 @@ func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nod
 		bindingKeys = append(bindingKeys, fmt.Sprintf(bindingKeyRecoveryTemplate, nodeID))
 	}
 
-	tls := &tls.Config{
-		ServerName:         server,
-		InsecureSkipVerify: true,
-	}
-	conn, err := amqp.DialTLS(addr, tls)
+	cfg = cfg.withDefaults()
+	conn, err := amqp.DialConfig(addr, amqp.Config{
+		Heartbeat: cfg.Heartbeat,
+		TLSClientConfig: &tls.Config{
+			ServerName:         server,
+			InsecureSkipVerify: true,
+		},
+		Dial: amqp.DefaultDial(cfg.ConnectionTimeout),
+	})
 	if err != nil {
 		return nil, uof.Notice("conn.Dial", err)
 	}
@@ -158,6 +242,12 @@ func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nod
 		return nil, uof.Notice("conn.Channel", err)
 	}
 
+	if cfg.Prefetch > 0 {
+		if err := chnl.Qos(cfg.Prefetch, 0, false); err != nil {
+			return nil, uof.Notice("conn.Qos", err)
+		}
+	}
+
 	qee, err := chnl.QueueDeclare(
 		"",    // name, leave empty to generate a unique name
 		false, // durable
@@ -185,13 +275,13 @@ func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nod
 
 	consumerTag := ""
 	msgs, err := chnl.Consume(
-		qee.Name,    // queue
-		consumerTag, // consumerTag
-		true,        // auto-ack
-		true,        // exclusive
-		false,       // no-local
-		false,       // no-wait
-		nil,         // args
+		qee.Name,          // queue
+		consumerTag,       // consumerTag
+		cfg.Prefetch <= 0, // auto-ack
+		true,              // exclusive
+		false,             // no-local
+		false,             // no-wait
+		nil,               // args
 	)
 	if err != nil {
 		return nil, uof.Notice("conn.Consume", err)
@@ -204,7 +294,7 @@ func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nod
 		msgs: msgs,
 		errs: errs,
 		reDial: func() (*Connection, error) {
-			return dial(ctx, server, bookmakerID, token, bind, nodeID)
+			return dial(ctx, server, bookmakerID, token, bind, nodeID, cfg)
 		},
 		info: ConnectionInfo{
 			server:     server,
@@ -212,6 +302,8 @@ func dial(ctx context.Context, server, bookmakerID, token string, bind int8, nod
 			network:    conn.LocalAddr().Network(),
 			tlsVersion: conn.ConnectionState().Version,
 		},
+		cfg:   cfg,
+		stats: &stats{},
 	}
 
 	go func() {
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+type acknowledgerMock struct {
+	acks []uint64
+	sync.Mutex
+}
+
+func (a *acknowledgerMock) Ack(tag uint64, multiple bool) error {
+	a.Lock()
+	defer a.Unlock()
+	a.acks = append(a.acks, tag)
+	return nil
+}
+func (a *acknowledgerMock) Nack(tag uint64, multiple bool, requeue bool) error { return nil }
+func (a *acknowledgerMock) Reject(tag uint64, requeue bool) error              { return nil }
+
+func TestDrainAck(t *testing.T) {
+	ack := &acknowledgerMock{}
+	msgs := make(chan amqp.Delivery, 2)
+	msgs <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 1, RoutingKey: "-.-.-.alive.-.-.-.-",
+		Body: []byte(`<alive product="1" timestamp="1" subscribed="1"/>`)}
+	msgs <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 2, RoutingKey: "-.-.-.alive.-.-.-.-",
+		Body: []byte(`<alive`)}
+	close(msgs)
+	errs := make(chan *amqp.Error)
+	close(errs)
+
+	c := &Connection{msgs: msgs, errs: errs, cfg: Config{Prefetch: 1}, stats: &stats{}}
+	out, errc := c.Listen()
+	m := <-out
+	assert.Equal(t, uof.MessageTypeAlive, m.Type)
+	assert.Error(t, <-errc)
+	for range out {
+	}
+	// parse errors are also acknowledged
+	ack.Lock()
+	defer ack.Unlock()
+	assert.Equal(t, []uint64{1, 2}, ack.acks)
+}
@@ -6,15 +6,58 @@ import (
 
 	"github.com/cenkalti/backoff/v3"
 	"github.com/minus5/go-uof-sdk"
-	"github.com/pkg/errors"
 )
 
-var (
-	maxInterval    = 16 * time.Second // max interval for exponential backoff
-	maxElapsedTime = 1 * time.Hour    // will give up if not connected longer than this
+const (
+	defaultInitialInterval = 500 * time.Millisecond
+	defaultMaxInterval     = 16 * time.Second // max interval for exponential backoff
+	defaultMaxElapsedTime  = 1 * time.Hour    // will give up if not connected longer than this
 )
 
-// WithReconnect ensuers reconnects with exponential backoff interval
+// ReconnectPolicy configures exponential backoff between reconnect attempts.
+// Zero values are replaced with defaults.
+type ReconnectPolicy struct {
+	// InitialInterval wait before the second attempt.
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between two attempts.
+	MaxInterval time.Duration
+	// MaxElapsedTime after which reconnect gives up.
+	MaxElapsedTime time.Duration
+	// MaxAttempts after which reconnect gives up, zero is not limiting.
+	MaxAttempts int
+	// Infinite never gives up, MaxElapsedTime and MaxAttempts are ignored.
+	Infinite bool
+}
+
+func (p ReconnectPolicy) withDefaults() ReconnectPolicy {
+	if p.InitialInterval == 0 {
+		p.InitialInterval = defaultInitialInterval
+	}
+	if p.MaxInterval == 0 {
+		p.MaxInterval = defaultMaxInterval
+	}
+	if p.MaxElapsedTime == 0 {
+		p.MaxElapsedTime = defaultMaxElapsedTime
+	}
+	return p
+}
+
+func (p ReconnectPolicy) backOff() *backoff.ExponentialBackOff {
+	p = p.withDefaults()
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = p.InitialInterval
+	b.MaxInterval = p.MaxInterval
+	b.MaxElapsedTime = p.MaxElapsedTime
+	if p.Infinite {
+		b.MaxElapsedTime = 0
+	}
+	return b
+}
+
+// WithReconnect ensuers reconnects with exponential backoff interval.
+//
+// Each failed attempt is reported as uof.ReconnectError. When reconnect policy
+// gives up uof.ReconnectGiveUpError is reported and source is closed.
 func WithReconnect(ctx context.Context, conn *Connection) func() (<-chan *uof.Message, <-chan error) {
 	return func() (<-chan *uof.Message, <-chan error) {
 		out := make(chan *uof.Message)
@@ -29,20 +72,24 @@ func WithReconnect(ctx context.Context, conn *Connection) func() (<-chan *uof.Me
 			}
 		}
 
+		attempt := 0
 		reconnect := func() error {
+			attempt++
 			nc, err := conn.reDial()
-			if err == nil {
-				conn = nc // replace existing with new connection
-			}
 			if err != nil {
-				errc <- errors.Wrap(err, "reconnect failed")
+				errc <- uof.Notice("queue.reconnect", uof.ReconnectError{Attempt: attempt, Inner: err})
+				return err
 			}
-			return err
+			nc.stats = conn.stats // keep counting on the new connection
+			nc.stats.reconnect()
+			conn = nc // replace existing with new connection
+			return nil
 		}
 
 		go func() {
 			defer close(out)
 			defer close(errc)
+			defer conn.stats.report(conn.cfg.StatsInterval, out)()
 			for {
 				// signal connect
 				out <- uof.NewDetailedConnnectionMessage(uof.ConnectionStatusUp, conn.info.server, conn.info.local, conn.info.network, conn.info.tlsVersion)
@@ -52,7 +99,16 @@ func WithReconnect(ctx context.Context, conn *Connection) func() (<-chan *uof.Me
 				}
 				// signal connection lost
 				out <- uof.NewSimpleConnnectionMessage(uof.ConnectionStatusDown)
-				if err := withBackoff(ctx, reconnect, maxInterval, maxElapsedTime); err != nil {
+				attempt = 0
+				started := time.Now()
+				if err := withBackoff(ctx, reconnect, conn.cfg.Reconnect); err != nil {
+					if !done() {
+						errc <- uof.Notice("queue.reconnect", uof.ReconnectGiveUpError{
+							Attempts: attempt,
+							Elapsed:  time.Since(started),
+							Inner:    err,
+						})
+					}
 					return
 				}
 			}
@@ -62,11 +118,10 @@ func WithReconnect(ctx context.Context, conn *Connection) func() (<-chan *uof.Me
 	}
 }
 
-func withBackoff(ctx context.Context, op func() error,
-	maxInterval, maxElapsedTime time.Duration) error {
-	b := backoff.NewExponentialBackOff()
-	b.MaxInterval = maxInterval
-	b.MaxElapsedTime = maxElapsedTime
-	bc := backoff.WithContext(b, ctx)
-	return backoff.Retry(op, bc)
+func withBackoff(ctx context.Context, op func() error, p ReconnectPolicy) error {
+	var b backoff.BackOff = p.backOff()
+	if p.MaxAttempts > 0 && !p.Infinite {
+		b = backoff.WithMaxRetries(b, uint64(p.MaxAttempts-1))
+	}
+	return backoff.Retry(op, backoff.WithContext(b, ctx))
 }
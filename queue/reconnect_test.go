@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectPolicy(t *testing.T) {
+	b := ReconnectPolicy{}.backOff()
+	assert.Equal(t, defaultInitialInterval, b.InitialInterval)
+	assert.Equal(t, defaultMaxInterval, b.MaxInterval)
+	assert.Equal(t, defaultMaxElapsedTime, b.MaxElapsedTime)
+
+	b = ReconnectPolicy{MaxElapsedTime: time.Minute, Infinite: true}.backOff()
+	assert.Equal(t, time.Duration(0), b.MaxElapsedTime)
+
+	attempts := 0
+	op := func() error {
+		attempts++
+		return fmt.Errorf("failed")
+	}
+	p := ReconnectPolicy{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		MaxElapsedTime:  10 * time.Millisecond,
+	}
+	err := withBackoff(context.Background(), op, p)
+	assert.Error(t, err)
+	assert.True(t, attempts > 1)
+
+	attempts = 0
+	p = ReconnectPolicy{InitialInterval: time.Millisecond, MaxAttempts: 3}
+	assert.Error(t, withBackoff(context.Background(), op, p))
+	assert.Equal(t, 3, attempts)
+}
+
+// failingConnection is already closed and fails each redial with err
+func failingConnection(cfg Config, err error) *Connection {
+	msgs := make(chan amqp.Delivery)
+	close(msgs)
+	errs := make(chan *amqp.Error)
+	close(errs)
+	return &Connection{
+		msgs:   msgs,
+		errs:   errs,
+		reDial: func() (*Connection, error) { return nil, err },
+		cfg:    cfg,
+		stats:  &stats{},
+	}
+}
+
+func TestWithReconnectGiveUp(t *testing.T) {
+	policies := []ReconnectPolicy{
+		{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: 20 * time.Millisecond},
+		{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxAttempts: 3},
+	}
+	for _, p := range policies {
+		dialErr := errors.New("dial failed")
+		conn := failingConnection(Config{Reconnect: p}, dialErr)
+		out, errc := WithReconnect(context.Background(), conn)()
+		go func() {
+			for range out {
+			}
+		}()
+
+		reconnects := 0
+		var gu uof.ReconnectGiveUpError
+		for err := range errc {
+			var re uof.ReconnectError
+			if errors.As(err, &re) {
+				reconnects++
+				continue
+			}
+			assert.True(t, errors.As(err, &gu))
+			assert.True(t, errors.Is(err, dialErr))
+		}
+		assert.Equal(t, reconnects, gu.Attempts)
+		if p.MaxAttempts > 0 {
+			assert.Equal(t, p.MaxAttempts, gu.Attempts)
+		} else {
+			assert.True(t, gu.Attempts > 1)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+)
+
+// stats counts deliveries on the connection. It is shared between connections
+// created on reconnect so the totals survive connection loss.
+type stats struct {
+	messages     int
+	bytes        int
+	parseErrors  int
+	reconnects   int
+	lastDelivery int // timestamp of the last delivery
+	lastReport   int // timestamp of the previous snapshot
+	sync.Mutex
+}
+
+func (s *stats) delivery(size int) {
+	s.Lock()
+	defer s.Unlock()
+	s.messages++
+	s.bytes += size
+	s.lastDelivery = uof.CurrentTimestamp()
+}
+
+func (s *stats) parseError() {
+	s.Lock()
+	defer s.Unlock()
+	s.parseErrors++
+}
+
+func (s *stats) reconnect() {
+	s.Lock()
+	defer s.Unlock()
+	s.reconnects++
+}
+
+// snapshot returns stats since the previous snapshot and resets interval
+// counters
+func (s *stats) snapshot(ts int) uof.ConnectionStats {
+	s.Lock()
+	defer s.Unlock()
+
+	cs := uof.ConnectionStats{
+		Messages:    s.messages,
+		Bytes:       s.bytes,
+		ParseErrors: s.parseErrors,
+		Reconnects:  s.reconnects,
+	}
+	if s.lastReport > 0 && ts > s.lastReport {
+		cs.Interval = ts - s.lastReport
+		seconds := float64(cs.Interval) / 1000
+		cs.MessagesPerSecond = float64(s.messages) / seconds
+		cs.BytesPerSecond = float64(s.bytes) / seconds
+	}
+	if s.lastDelivery > 0 {
+		cs.SinceLastDelivery = ts - s.lastDelivery
+	}
+	s.messages = 0
+	s.bytes = 0
+	s.lastReport = ts
+	return cs
+}
+
+// report starts sending connection stats messages to the out every interval.
+// Returns func which stops reporting, out must not be closed before that.
+func (s *stats) report(interval time.Duration, out chan<- *uof.Message) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	s.snapshot(uof.CurrentTimestamp()) // start the first interval now
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				m := uof.NewConnectionStatsMessage(s.snapshot(uof.CurrentTimestamp()))
+				select {
+				case out <- m:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
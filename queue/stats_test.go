@@ -0,0 +1,46 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minus5/go-uof-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsSnapshot(t *testing.T) {
+	s := &stats{}
+	s.snapshot(1000)
+	s.delivery(100)
+	s.delivery(300)
+	s.parseError()
+	s.reconnect()
+	s.lastDelivery = 2500
+
+	cs := s.snapshot(3000)
+	assert.Equal(t, 2000, cs.Interval)
+	assert.Equal(t, 2, cs.Messages)
+	assert.Equal(t, 400, cs.Bytes)
+	assert.Equal(t, float64(1), cs.MessagesPerSecond)
+	assert.Equal(t, float64(200), cs.BytesPerSecond)
+	assert.Equal(t, 1, cs.ParseErrors)
+	assert.Equal(t, 1, cs.Reconnects)
+	assert.Equal(t, 500, cs.SinceLastDelivery)
+
+	// interval counters are reset, totals are not
+	cs = s.snapshot(4000)
+	assert.Equal(t, 0, cs.Messages)
+	assert.Equal(t, 1, cs.ParseErrors)
+	assert.Equal(t, 1, cs.Reconnects)
+}
+
+func TestStatsReport(t *testing.T) {
+	s := &stats{}
+	out := make(chan *uof.Message)
+	stop := s.report(time.Millisecond, out)
+	m := <-out
+	stop()
+	assert.Equal(t, uof.MessageTypeConnectionStats, m.Type)
+	assert.Equal(t, uof.MessageScopeSystem, m.Scope)
+	assert.NotNil(t, m.ConnectionStats)
+}
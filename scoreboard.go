@@ -0,0 +1,342 @@
+package uof
+
+import "fmt"
+
+// SportFamily groups sports with the same live scoreboard attributes.
+type SportFamily int8
+
+const (
+	SportFamilyGeneric SportFamily = iota // only home and away score
+	SportFamilyTennis                     // tennis
+	SportFamilyBaseball
+	SportFamilyCricket
+	SportFamilyDarts
+	SportFamilySnooker
+	SportFamilyAmericanFootball
+	SportFamilyBowls
+	SportFamilyCounterStrike
+	SportFamilyRally // table tennis, badminton; games of plain points
+)
+
+var sportFamilies = map[int]SportFamily{
+	3:   SportFamilyBaseball,
+	5:   SportFamilyTennis,
+	16:  SportFamilyAmericanFootball,
+	19:  SportFamilySnooker,
+	20:  SportFamilyRally, // table tennis
+	21:  SportFamilyCricket,
+	22:  SportFamilyDarts,
+	31:  SportFamilyRally, // badminton
+	32:  SportFamilyBowls,
+	109: SportFamilyCounterStrike,
+}
+
+// Family of the sport.
+func SportFamilyOf(sportID int) SportFamily {
+	return sportFamilies[sportID]
+}
+
+func (f SportFamily) String() string {
+	switch f {
+	case SportFamilyTennis:
+		return "tennis"
+	case SportFamilyBaseball:
+		return "baseball"
+	case SportFamilyCricket:
+		return "cricket"
+	case SportFamilyDarts:
+		return "darts"
+	case SportFamilySnooker:
+		return "snooker"
+	case SportFamilyAmericanFootball:
+		return "american_football"
+	case SportFamilyBowls:
+		return "bowls"
+	case SportFamilyCounterStrike:
+		return "counter_strike"
+	case SportFamilyRally:
+		return "rally"
+	default:
+		return "generic"
+	}
+}
+
+// MarshalText so family is string in json.
+func (f SportFamily) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// Scoreboard is typed view of the SportEventStatus. Only the view of the
+// sport family is set.
+type Scoreboard struct {
+	SportID     int         `json:"sportID"`
+	Family      SportFamily `json:"family"`
+	Status      EventStatus `json:"status"`
+	MatchStatus *int        `json:"matchStatus,omitempty"`
+	HomeScore   int         `json:"homeScore"`
+	AwayScore   int         `json:"awayScore"`
+	Periods     []Score     `json:"periods,omitempty"`
+
+	Tennis           *TennisScore           `json:"tennis,omitempty"`
+	Baseball         *BaseballState         `json:"baseball,omitempty"`
+	Cricket          *CricketState          `json:"cricket,omitempty"`
+	Darts            *DartsState            `json:"darts,omitempty"`
+	Snooker          *SnookerState          `json:"snooker,omitempty"`
+	AmericanFootball *AmericanFootballState `json:"americanFootball,omitempty"`
+	Bowls            *BowlsState            `json:"bowls,omitempty"`
+	CounterStrike    *CounterStrikeState    `json:"counterStrike,omitempty"`
+	Rally            *RallyScore            `json:"rally,omitempty"`
+}
+
+// Score of the period (set, quarter...).
+type Score struct {
+	Number int `json:"number"`
+	Home   int `json:"home"`
+	Away   int `json:"away"`
+}
+
+// TennisScore sets are in the Scoreboard home/away score, games of each set
+// in Periods.
+type TennisScore struct {
+	HomeGames  int    `json:"homeGames"` // in the current set
+	AwayGames  int    `json:"awayGames"`
+	HomePoints string `json:"homePoints"` // 0, 15, 30, 40, A or tiebreak points
+	AwayPoints string `json:"awayPoints"`
+	Tiebreak   bool   `json:"tiebreak,omitempty"`
+	Server     *Team  `json:"server,omitempty"`
+	Expedite   bool   `json:"expedite,omitempty"`
+}
+
+// RallyScore games are in the Scoreboard home/away score, points of each game
+// in Periods.
+type RallyScore struct {
+	HomePoints int   `json:"homePoints"` // in the current game
+	AwayPoints int   `json:"awayPoints"`
+	Server     *Team `json:"server,omitempty"`
+}
+
+type BaseballState struct {
+	Outs       int    `json:"outs"`
+	Balls      int    `json:"balls"`
+	Strikes    int    `json:"strikes"`
+	Bases      string `json:"bases,omitempty"` // as received
+	HomeBatter int    `json:"homeBatter,omitempty"`
+	AwayBatter int    `json:"awayBatter,omitempty"`
+}
+
+type CricketState struct {
+	Innings         int `json:"innings"`
+	Over            int `json:"over"`
+	Delivery        int `json:"delivery"`
+	HomeDismissals  int `json:"homeDismissals"`
+	AwayDismissals  int `json:"awayDismissals"`
+	HomePenaltyRuns int `json:"homePenaltyRuns,omitempty"`
+	AwayPenaltyRuns int `json:"awayPenaltyRuns,omitempty"`
+}
+
+// DartsState sets are in the Scoreboard home/away score.
+type DartsState struct {
+	HomeLegs int   `json:"homeLegs"`
+	AwayLegs int   `json:"awayLegs"`
+	Throw    *Team `json:"throw,omitempty"`
+	Visit    int   `json:"visit,omitempty"`
+}
+
+// SnookerState frames are in the Scoreboard home/away score.
+type SnookerState struct {
+	RemainingReds int `json:"remainingReds"`
+}
+
+type AmericanFootballState struct {
+	Possession *Team `json:"possession,omitempty"`
+	Down       int   `json:"down,omitempty"` // try
+	Yards      int   `json:"yards,omitempty"`
+	Position   int   `json:"position,omitempty"`
+}
+
+type BowlsState struct {
+	CurrentEnd         int `json:"currentEnd"`
+	HomeRemainingBowls int `json:"homeRemainingBowls"`
+	AwayRemainingBowls int `json:"awayRemainingBowls"`
+}
+
+type CounterStrikeState struct {
+	CurrentCtTeam *Team `json:"currentCtTeam,omitempty"`
+}
+
+// NewScoreboard creates typed view of the sport event status.
+// Returns error if some value is out of the valid range for the sport, the
+// scoreboard is still filled with received values.
+func NewScoreboard(sportID int, s SportEventStatus) (Scoreboard, error) {
+	b := Scoreboard{
+		SportID:     sportID,
+		Family:      SportFamilyOf(sportID),
+		Status:      s.Status,
+		MatchStatus: s.MatchStatus,
+		HomeScore:   intValue(s.HomeScore),
+		AwayScore:   intValue(s.AwayScore),
+	}
+	for i, p := range s.PeriodScores {
+		n := i + 1
+		if p.Number != nil {
+			n = *p.Number
+		}
+		b.Periods = append(b.Periods, Score{Number: n, Home: intValue(p.HomeScore), Away: intValue(p.AwayScore)})
+	}
+	v := validator{}
+	v.nonNegative("score", s.HomeScore, s.AwayScore)
+
+	switch b.Family {
+	case SportFamilyTennis:
+		t := &TennisScore{
+			HomePoints: points(s.HomeGamescore, s.Tiebreak),
+			AwayPoints: points(s.AwayGamescore, s.Tiebreak),
+			Tiebreak:   boolValue(s.Tiebreak, false),
+			Server:     s.CurrentServer,
+			Expedite:   boolValue(s.ExpediteMode, false),
+		}
+		if n := len(b.Periods); n > 0 {
+			t.HomeGames, t.AwayGames = b.Periods[n-1].Home, b.Periods[n-1].Away
+		}
+		if !t.Tiebreak {
+			v.oneOf("gamescore", []int{0, 15, 30, 40, 50}, s.HomeGamescore, s.AwayGamescore)
+		}
+		v.nonNegative("gamescore", s.HomeGamescore, s.AwayGamescore)
+		b.Tennis = t
+	case SportFamilyBaseball:
+		b.Baseball = &BaseballState{
+			Outs:       intValue(s.Outs),
+			Balls:      intValue(s.Balls),
+			Strikes:    intValue(s.Strikes),
+			HomeBatter: intValue(s.HomeBatter),
+			AwayBatter: intValue(s.AwayBatter),
+		}
+		if s.Bases != nil {
+			b.Baseball.Bases = *s.Bases
+		}
+		v.between("outs", 0, 3, s.Outs)
+		v.between("balls", 0, 4, s.Balls)
+		v.between("strikes", 0, 3, s.Strikes)
+	case SportFamilyCricket:
+		b.Cricket = &CricketState{
+			Innings:         intValue(s.Innings),
+			Over:            intValue(s.Over),
+			Delivery:        intValue(s.Delivery),
+			HomeDismissals:  intValue(s.HomeDismissals),
+			AwayDismissals:  intValue(s.AwayDismissals),
+			HomePenaltyRuns: intValue(s.HomePenaltyRuns),
+			AwayPenaltyRuns: intValue(s.AwayPenaltyRuns),
+		}
+		v.between("dismissals", 0, 10, s.HomeDismissals, s.AwayDismissals)
+		v.nonNegative("innings", s.Innings, s.Over, s.Delivery)
+	case SportFamilyDarts:
+		b.Darts = &DartsState{
+			HomeLegs: intValue(s.HomeLegscore),
+			AwayLegs: intValue(s.AwayLegscore),
+			Throw:    teamValue(s.Throw),
+			Visit:    intValue(s.Visit),
+		}
+		v.nonNegative("legscore", s.HomeLegscore, s.AwayLegscore)
+	case SportFamilySnooker:
+		b.Snooker = &SnookerState{RemainingReds: intValue(s.RemainingReds)}
+		v.between("remaining reds", 0, 15, s.RemainingReds)
+	case SportFamilyAmericanFootball:
+		b.AmericanFootball = &AmericanFootballState{
+			Possession: teamValue(s.Possession),
+			Down:       intValue(s.Try),
+			Yards:      intValue(s.Yards),
+			Position:   intValue(s.Position),
+		}
+		v.between("try", 0, 4, s.Try)
+	case SportFamilyBowls:
+		b.Bowls = &BowlsState{
+			CurrentEnd:         intValue(s.CurrentEnd),
+			HomeRemainingBowls: intValue(s.HomeRemainingBowls),
+			AwayRemainingBowls: intValue(s.AwayRemainingBowls),
+		}
+		v.nonNegative("bowls", s.CurrentEnd, s.HomeRemainingBowls, s.AwayRemainingBowls)
+	case SportFamilyCounterStrike:
+		b.CounterStrike = &CounterStrikeState{CurrentCtTeam: s.CurrentCtTeam}
+	case SportFamilyRally:
+		r := &RallyScore{Server: s.CurrentServer}
+		if n := len(b.Periods); n > 0 {
+			r.HomePoints, r.AwayPoints = b.Periods[n-1].Home, b.Periods[n-1].Away
+		}
+		for _, p := range s.PeriodScores {
+			v.nonNegative("points", p.HomeScore, p.AwayScore)
+		}
+		b.Rally = r
+	}
+	return b, v.err
+}
+
+// points of the tennis game, 50 is advantage
+func points(p *int, tiebreak *bool) string {
+	if p == nil {
+		return "0"
+	}
+	if *p == 50 && !boolValue(tiebreak, false) {
+		return "A"
+	}
+	return fmt.Sprintf("%d", *p)
+}
+
+func intValue(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// teamValue of the int attribute which holds team (1 home, 2 away)
+func teamValue(v *int) *Team {
+	if v == nil || (*v != int(TeamHome) && *v != int(TeamAway)) {
+		return nil
+	}
+	t := Team(*v)
+	return &t
+}
+
+// validator keeps the first validation error
+type validator struct {
+	err error
+}
+
+func (v *validator) fail(name string, value int) {
+	if v.err == nil {
+		v.err = E("scoreboard", fmt.Errorf("invalid %s %d", name, value))
+	}
+}
+
+func (v *validator) nonNegative(name string, values ...*int) {
+	for _, p := range values {
+		if p != nil && *p < 0 {
+			v.fail(name, *p)
+		}
+	}
+}
+
+func (v *validator) between(name string, min, max int, values ...*int) {
+	for _, p := range values {
+		if p != nil && (*p < min || *p > max) {
+			v.fail(name, *p)
+		}
+	}
+}
+
+func (v *validator) oneOf(name string, valid []int, values ...*int) {
+	for _, p := range values {
+		if p == nil {
+			continue
+		}
+		ok := false
+		for _, e := range valid {
+			if *p == e {
+				ok = true
+			}
+		}
+		if !ok {
+			v.fail(name, *p)
+		}
+	}
+}
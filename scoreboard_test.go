@@ -0,0 +1,64 @@
+package uof
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreboard(t *testing.T) {
+	i := func(v int) *int { return &v }
+
+	b, err := NewScoreboard(5, SportEventStatus{
+		Status:        EventStatusLive,
+		HomeScore:     i(1),
+		AwayScore:     i(0),
+		HomeGamescore: i(50),
+		AwayGamescore: i(40),
+		PeriodScores: []PeriodScore{
+			{Number: i(1), HomeScore: i(6), AwayScore: i(4)},
+			{Number: i(2), HomeScore: i(3), AwayScore: i(2)},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, SportFamilyTennis, b.Family)
+	assert.Equal(t, "A", b.Tennis.HomePoints)
+	assert.Equal(t, "40", b.Tennis.AwayPoints)
+	assert.Equal(t, 3, b.Tennis.HomeGames)
+	assert.Len(t, b.Periods, 2)
+	assert.Nil(t, b.Baseball)
+
+	buf, err := json.Marshal(b)
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), `"family":"tennis"`)
+
+	b, err = NewScoreboard(3, SportEventStatus{Outs: i(2), Balls: i(5), Bases: new(string)})
+	assert.Error(t, err)
+	assert.Equal(t, 2, b.Baseball.Outs)
+
+	b, err = NewScoreboard(22, SportEventStatus{HomeLegscore: i(2), AwayLegscore: i(1), Throw: i(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, TeamAway, *b.Darts.Throw)
+
+	// table tennis points are not tennis points
+	b, err = NewScoreboard(20, SportEventStatus{
+		HomeScore: i(1),
+		AwayScore: i(0),
+		PeriodScores: []PeriodScore{
+			{Number: i(1), HomeScore: i(11), AwayScore: i(9)},
+			{Number: i(2), HomeScore: i(7), AwayScore: i(10)},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, SportFamilyRally, b.Family)
+	assert.Nil(t, b.Tennis)
+	assert.Equal(t, 7, b.Rally.HomePoints)
+	assert.Equal(t, 10, b.Rally.AwayPoints)
+	assert.Equal(t, SportFamilyRally, SportFamilyOf(31))
+
+	b, err = NewScoreboard(1, SportEventStatus{HomeScore: i(2), AwayScore: i(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, SportFamilyGeneric, b.Family)
+	assert.Equal(t, 2, b.HomeScore)
+}
@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/minus5/go-uof-sdk"
@@ -16,6 +17,7 @@ type Config struct {
 	BookmakerID string
 	Token       string
 	Fixtures    time.Time
+	Preload     pipe.PreloadConfig
 	Recovery    []uof.ProducerChange
 	Stages      []pipe.InnerStage
 	Env         uof.Environment
@@ -26,6 +28,34 @@ type Config struct {
 	BindLive     bool
 	Languages    []uof.Lang
 	NodeID       int
+	Queue        queue.Config
+	CapturePath  string
+	ReplayPath   string
+	ReplaySpeed  float64
+	Source       pipe.SourceStage
+	API          *api.API
+	OddsDiff     bool
+	Competitors  bool
+	Catalogue    bool
+	Dedup        *pipe.DedupConfig
+	Ledger       *pipe.SettlementLedger
+	Tracker      *pipe.EventTracker
+	// Coalesce is the window in which odds changes of the event are merged,
+	// zero disables coalescing.
+	Coalesce time.Duration
+	// Timeline is the interval of the match timeline fetches for each live
+	// event, zero disables timeline stage.
+	Timeline time.Duration
+	Summary  *pipe.SummaryConfig
+	// PollChanges is the interval of fixture and result changes polling,
+	// zero disables polling.
+	PollChanges time.Duration
+	// MatchStatuses loads match status descriptions from the api into
+	// uof.MatchStatuses on start.
+	MatchStatuses bool
+	// FixturePolicies decide when fixtures are fetched, for each producer.
+	FixturePolicies pipe.FixturePolicies
+	FixtureDiffs    *pipe.FixtureDiffs
 }
 
 // Option sets attributes on the Config.
@@ -34,40 +64,97 @@ type Option func(*Config)
 // Run starts uof connector.
 //
 // Call to Run blocks until stopped by context, or error occurred.
+// If the queue reconnect gives up Run returns uof.ReconnectGiveUpError (check
+// with errors.As), after clean shutdown it returns nil or first error seen.
 // Order in which options are set is not important.
 // Credentials and one of Callback or Pipe are functional minimum.
 func Run(ctx context.Context, options ...Option) error {
 	c := config(options...)
-	qc, apiConn, err := connect(ctx, c)
+	if c.CapturePath != "" {
+		cpt, err := queue.NewCapture(c.CapturePath)
+		if err != nil {
+			return err
+		}
+		defer cpt.Close()
+		c.Queue.Capture = cpt
+	}
+	source, apiConn, err := connect(ctx, c)
 	if err != nil {
 		return err
 	}
+	if c.MatchStatuses {
+		if err := loadMatchStatuses(apiConn, c.Languages); err != nil {
+			return err
+		}
+	}
 
-	stages := []pipe.InnerStage{
-		pipe.Markets(apiConn, c.Languages),
-		pipe.Fixture(apiConn, c.Languages, c.Fixtures),
+	var stages []pipe.InnerStage
+	if c.Dedup != nil {
+		stages = append(stages, pipe.Dedup(*c.Dedup))
+	}
+	stages = append(stages, pipe.Markets(apiConn, c.Languages))
+	if c.Catalogue {
+		stages = append(stages, pipe.Catalogue(apiConn, c.Languages))
+	}
+	preload := c.Preload
+	if preload.To.IsZero() {
+		preload.To = c.Fixtures
+	}
+	stages = append(stages,
+		pipe.FixtureStage(apiConn, c.Languages, pipe.FixtureConfig{Preload: preload, Policies: c.FixturePolicies}),
 		pipe.Player(apiConn, c.Languages),
-		//pipe.Competitor(apiConn, c.Languages),
-		pipe.BetStop(),
+	)
+	if c.Competitors {
+		stages = append(stages, pipe.Competitor(apiConn, c.Languages))
+	}
+	stages = append(stages, pipe.BetStop())
+	if c.Coalesce > 0 {
+		stages = append(stages, pipe.Coalesce(c.Coalesce))
+	}
+	if c.OddsDiff {
+		stages = append(stages, pipe.OddsDiff())
+	}
+	if c.Ledger != nil {
+		stages = append(stages, c.Ledger.Stage())
+	}
+	if c.Tracker != nil {
+		stages = append(stages, c.Tracker.Stage())
+	}
+	if c.Timeline > 0 {
+		stages = append(stages, pipe.Timeline(apiConn, c.Languages, c.Timeline))
+	}
+	if c.Summary != nil {
+		stages = append(stages, pipe.Summary(apiConn, c.Languages, *c.Summary))
+	}
+	if c.PollChanges > 0 {
+		stages = append(stages, pipe.ChangesPoll(apiConn, c.Languages, c.PollChanges))
+	}
+	if c.FixtureDiffs != nil {
+		stages = append(stages, c.FixtureDiffs.Stage())
 	}
 	if len(c.Recovery) > 0 {
 		stages = append(stages, pipe.Recovery(apiConn, c.Recovery))
 	}
 	stages = append(stages, c.Stages...)
 
-	errc := pipe.Build(
-		queue.WithReconnect(ctx, qc),
-		stages...,
-	)
+	errc := pipe.Build(source, stages...)
 	return firstErr(errc)
 }
 
+// firstErr returns first error from errc, or terminal reconnect error if
+// there was one
 func firstErr(errc <-chan error) error {
-	var err error
+	var err, terminal error
 	for e := range errc {
 		if err == nil {
 			err = e
 		}
+		if errors.As(e, &uof.ReconnectGiveUpError{}) {
+			terminal = e
+		}
+	}
+	if terminal != nil {
+		return terminal
 	}
 	return err
 }
@@ -84,8 +171,35 @@ func config(options ...Option) Config {
 	return *c
 }
 
+func loadMatchStatuses(a *api.API, languages []uof.Lang) error {
+	for _, lang := range languages {
+		ds, err := a.MatchStatuses(lang)
+		if err != nil {
+			return err
+		}
+		uof.MatchStatuses.Load(lang, ds)
+	}
+	return nil
+}
+
 // connect to the queue and api
-func connect(ctx context.Context, c Config) (*queue.Connection, *api.API, error) {
+// Queue connection is skipped if the source or replay is set in the config.
+// Api connection is skipped if the api is set.
+func connect(ctx context.Context, c Config) (pipe.SourceStage, *api.API, error) {
+	stg := c.API
+	if stg == nil {
+		var err error
+		stg, err = api.Dial(ctx, c.Env, c.Token, c.NodeID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if c.Source != nil {
+		return c.Source, stg, nil
+	}
+	if c.ReplayPath != "" {
+		return queue.FromCapture(ctx, c.ReplayPath, c.ReplaySpeed), stg, nil
+	}
 	bind := queue.BindAll
 	if c.BindVirtuals {
 		bind = queue.BindVirtuals
@@ -99,15 +213,11 @@ func connect(ctx context.Context, c Config) (*queue.Connection, *api.API, error)
 	if c.BindLive {
 		bind = queue.BindLive
 	}
-	conn, err := queue.Dial(ctx, c.Env, c.BookmakerID, c.Token, bind, c.NodeID)
-	if err != nil {
-		return nil, nil, err
-	}
-	stg, err := api.Dial(ctx, c.Env, c.Token, c.NodeID)
+	conn, err := queue.Dial(ctx, c.Env, c.BookmakerID, c.Token, bind, c.NodeID, queue.WithConfig(c.Queue))
 	if err != nil {
 		return nil, nil, err
 	}
-	return conn, stg, nil
+	return queue.WithReconnect(ctx, conn), stg, nil
 }
 
 // Credentials for establishing connection to the uof queue and api.
@@ -187,6 +297,76 @@ func RecoveryNodeID(nodeID int) Option {
 	}
 }
 
+// Heartbeat sets amqp heartbeat interval. Connection loss is detected after two
+// missed heartbeats. Default is 10 seconds.
+func Heartbeat(interval time.Duration) Option {
+	return func(c *Config) {
+		c.Queue.Heartbeat = interval
+	}
+}
+
+// ConnectionTimeout limits time for establishing amqp connection (tcp connect
+// and tls handshake). Default is 30 seconds.
+func ConnectionTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Queue.ConnectionTimeout = timeout
+	}
+}
+
+// Prefetch sets channel level prefetch count for the amqp consumer. With
+// prefetch deliveries are acknowledged after they enter the pipe.
+func Prefetch(count int) Option {
+	return func(c *Config) {
+		c.Queue.Prefetch = count
+	}
+}
+
+// ConnectionStats enables periodic connection statistics messages.
+//
+// Every interval SDK will emit system message of type
+// uof.MessageTypeConnectionStats with throughput of the queue connection
+// (messages/sec, bytes/sec), number of parse errors, time since last delivery
+// and number of reconnects.
+func ConnectionStats(interval time.Duration) Option {
+	return func(c *Config) {
+		c.Queue.StatsInterval = interval
+	}
+}
+
+// Reconnect sets exponential backoff policy for reconnecting to the queue.
+//
+// By default SDK gives up after an hour of failed attempts, with up to 16
+// seconds between attempts. Set policy.MaxAttempts to limit number of
+// attempts, or policy.Infinite to never give up.
+func Reconnect(policy queue.ReconnectPolicy) Option {
+	return func(c *Config) {
+		c.Queue.Reconnect = policy
+	}
+}
+
+// Capture records every raw queue delivery (routing key, headers, receive
+// timestamp and body) into append-only capture file at path.
+//
+// Captured file can be replayed with FromCapture.
+func Capture(path string) Option {
+	return func(c *Config) {
+		c.CapturePath = path
+	}
+}
+
+// FromCapture uses capture file, recorded with Capture option, as source of
+// the messages instead of the queue connection. All other stages of the
+// pipeline are the same. Api connection is still used.
+//
+// Speed 1 replays deliveries with original timing, 10 is ten times faster,
+// zero replays without delay.
+func FromCapture(path string, speed float64) Option {
+	return func(c *Config) {
+		c.ReplayPath = path
+		c.ReplaySpeed = speed
+	}
+}
+
 // Consumer sets chan consumer of the SDK messages stream.
 //
 // Consumer should range over `in` chan and handle all messages.
@@ -206,6 +386,148 @@ func BufferedConsumer(consumer pipe.ConsumerStage, buffer int) Option {
 	}
 }
 
+// ShardedConsumer runs consumer in parallel shards created with
+// pipe.NewShardedConsumer.
+//
+// Messages of one event are always handled by the same shard, in order.
+// System and lexicon messages are delivered to all shards. Keep reference to
+// the sharded consumer to monitor shard queue depths.
+func ShardedConsumer(sc *pipe.ShardedConsumer) Option {
+	return func(c *Config) {
+		c.Stages = append(c.Stages, sc.Stage())
+	}
+}
+
+// GuardedConsumer same as consumer but with the queue configured by cfg.
+//
+// Overflow policy decides what happens when the queue is full: block,
+// drop superseded odds changes or spill to disk. When cfg.SlowThreshold is set
+// uof.MessageTypeSlowConsumer message is sent to all later consumers if this
+// one is stalled.
+func GuardedConsumer(consumer pipe.ConsumerStage, cfg pipe.ConsumerConfig) Option {
+	return func(c *Config) {
+		c.Stages = append(c.Stages, pipe.GuardedConsumer(consumer, cfg))
+	}
+}
+
+// Filtered sets consumer which receives only messages matching filter.
+//
+// System messages are always delivered. Other consumers are not affected by
+// the filter. Can be called multiple times.
+func Filtered(filter pipe.MessageFilter, consumer pipe.ConsumerStage) Option {
+	return func(c *Config) {
+		c.Stages = append(c.Stages, pipe.Consumer(pipe.FilteredConsumer(filter, consumer)))
+	}
+}
+
+// Dedup drops (or flags) event messages received more than once; in recovery
+// or from other nodes. Duplicates are removed before any other stage.
+func Dedup(cfg pipe.DedupConfig) Option {
+	return func(c *Config) {
+		c.Dedup = &cfg
+	}
+}
+
+// OddsDiff sets OddsChange.Diff on each odds change message; changes to the
+// previous known state of the event markets. Diff is also sent as odds diff
+// message after the odds change, so it is kept in stores.
+func OddsDiff() Option {
+	return func(c *Config) {
+		c.OddsDiff = true
+	}
+}
+
+// Catalogue loads sports, categories and tournaments on start. Catalogue
+// message with the navigation tree is sent to consumers for each language.
+func Catalogue() Option {
+	return func(c *Config) {
+		c.Catalogue = true
+	}
+}
+
+// Competitors fetches competitor profiles (players, jerseys, manager, venue)
+// for competitors of the fixtures and odds change outcomes. Competitor
+// message is sent to consumers for each language.
+func Competitors() Option {
+	return func(c *Config) {
+		c.Competitors = true
+	}
+}
+
+// SettlementLedger applies bet settlements, bet cancels and their rollbacks
+// to the ledger. Settlement change message is sent to consumers after each
+// change of the effective market line settlement.
+func SettlementLedger(l *pipe.SettlementLedger) Option {
+	return func(c *Config) {
+		c.Ledger = l
+	}
+}
+
+// EventTracker maintains lifecycle state of the events. Event transition
+// message is sent to consumers on each event status or match status change.
+// Use tracker State to find whether event is live.
+func EventTracker(t *pipe.EventTracker) Option {
+	return func(c *Config) {
+		c.Tracker = t
+	}
+}
+
+// FixtureDiffs keeps the last fixture of each event. Fixture diff message
+// with the changed fields is sent to consumers after each fixture which
+// changes start time, status, competitors, venue, tv channels, liveodds or
+// replaced by.
+func FixtureDiffs(d *pipe.FixtureDiffs) Option {
+	return func(c *Config) {
+		c.FixtureDiffs = d
+	}
+}
+
+// Timeline fetches match timeline of the live events on event status, match
+// status or score change, at most once in the interval for each event.
+// Timeline message is sent to consumers for each language.
+func Timeline(interval time.Duration) Option {
+	return func(c *Config) {
+		c.Timeline = interval
+	}
+}
+
+// Summary fetches event summary, with final scores and statistics, when
+// the event status is changed to one of the configured statuses (by default
+// ended or closed). Summary message is sent to consumers for each language.
+func Summary(cfg pipe.SummaryConfig) Option {
+	return func(c *Config) {
+		c.Summary = &cfg
+	}
+}
+
+// PollChanges polls fixture and result changes in the interval, and fetches
+// fixture or summary of the changed events. Use it as a safety net for the
+// missed fixture_change messages.
+func PollChanges(interval time.Duration) Option {
+	return func(c *Config) {
+		c.PollChanges = interval
+	}
+}
+
+// MatchStatuses loads match status descriptions for all languages from the
+// api. Without it embedded english descriptions are used by the
+// SportEventStatus helpers.
+func MatchStatuses() Option {
+	return func(c *Config) {
+		c.MatchStatuses = true
+	}
+}
+
+// Coalesce merges odds changes of the event received within window.
+//
+// All consumers, odds diff, ledger and tracker receive merged odds changes.
+// Other messages are never delayed.
+func Coalesce(window time.Duration) Option {
+	return func(c *Config) {
+		c.Coalesce = window
+	}
+}
+
 // Callback sets handler for all messages.
 //
 // If returns error will break the pipe and force exit from sdk.Run.
@@ -242,3 +564,22 @@ func Fixtures(to time.Time) Option {
 		c.Fixtures = to
 	}
 }
+
+// FixturePolicies sets fixture fetching policy for each producer; throttle,
+// change types fetched immediately and scheduled refreshes before the event
+// start and of the long running tournaments.
+func FixturePolicies(ps pipe.FixturePolicies) Option {
+	return func(c *Config) {
+		c.FixturePolicies = ps
+	}
+}
+
+// FixturePreload is Fixtures with the preload window (from, to), sport filter
+// and persistent fixture cache. With the cache directory set, restart reads
+// fixtures from the disk and fetches only those changed since the last
+// preload.
+func FixturePreload(cfg pipe.PreloadConfig) Option {
+	return func(c *Config) {
+		c.Preload = cfg
+	}
+}
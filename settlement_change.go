@@ -0,0 +1,73 @@
+package uof
+
+// SettlementChangeReason is message which changed effective settlement of
+// the market line.
+type SettlementChangeReason int8
+
+const (
+	SettlementChangeSettled SettlementChangeReason = iota + 1
+	SettlementChangeRollback
+	SettlementChangeCancel
+	SettlementChangeCancelRollback
+)
+
+func (r SettlementChangeReason) String() string {
+	switch r {
+	case SettlementChangeSettled:
+		return "settled"
+	case SettlementChangeRollback:
+		return "rollback"
+	case SettlementChangeCancel:
+		return "cancel"
+	case SettlementChangeCancelRollback:
+		return "cancel_rollback"
+	default:
+		return "?"
+	}
+}
+
+// SettlementChange is effective state of the market line after bet
+// settlement, bet cancel or their rollbacks are applied.
+// Outcomes are empty when line is not settled (or settlement is rolled back).
+// Cancellations lists bet cancel time windows which are still in effect.
+type SettlementChange struct {
+	EventID       int                    `json:"eventID"`
+	EventURN      URN                    `json:"eventURN"`
+	Producer      Producer               `json:"producer"`
+	Timestamp     int                    `json:"timestamp"` // of the message which caused the change
+	MarketID      int                    `json:"marketID"`
+	LineID        int                    `json:"lineID"`
+	Specifiers    map[string]string      `json:"specifiers,omitempty"`
+	Reason        SettlementChangeReason `json:"reason"`
+	Certainty     *int8                  `json:"certainty,omitempty"`
+	Outcomes      []BetSettlementOutcome `json:"outcomes,omitempty"`
+	Cancellations []CancelWindow         `json:"cancellations,omitempty"`
+	// Discrepancy are outcomes of the live settlement which are different
+	// from the confirmed one.
+	Discrepancy []BetSettlementOutcome `json:"discrepancy,omitempty"`
+}
+
+// Settled reports whether market line has settlement in effect.
+func (s SettlementChange) Settled() bool {
+	return len(s.Outcomes) > 0
+}
+
+// CancelWindow is bet cancel in effect for the market line. Bets placed
+// between StartTime and EndTime are cancelled, nil is open interval.
+type CancelWindow struct {
+	StartTime    *int    `json:"startTime,omitempty"`
+	EndTime      *int    `json:"endTime,omitempty"`
+	VoidReason   *int    `json:"voidReason,omitempty"`
+	SupercededBy *string `json:"supercededBy,omitempty"`
+}
+
+// Cancels reports whether bet placed at ts is cancelled by the window.
+func (w CancelWindow) Cancels(ts int) bool {
+	if w.StartTime != nil && ts < *w.StartTime {
+		return false
+	}
+	if w.EndTime != nil && ts > *w.EndTime {
+		return false
+	}
+	return true
+}